@@ -0,0 +1,357 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ====================== 多选 ======================
+
+// 标记或取消标记当前任务，并移到下一行方便连续标记
+func (m *Model) toggleMark() {
+	index := m.currentIndex()
+	if index < 0 {
+		return
+	}
+	id := m.items[index].id
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		m.marked[id] = true
+	}
+	m.moveCursor(1)
+}
+
+// 开始或结束范围选择：开始时记下起点，结束时把起点到光标之间的任务加入标记
+func (m *Model) toggleVisual() {
+	if m.visualAnchor != "" {
+		for _, id := range m.visualRange() {
+			m.marked[id] = true
+		}
+		m.visualAnchor = ""
+		m.statusLine = ""
+		return
+	}
+	if index := m.currentIndex(); index >= 0 {
+		m.visualAnchor = m.items[index].id
+		m.statusLine = "移动光标选择范围 • v 确定 • Esc 取消"
+	}
+}
+
+// 范围选择中起点到光标之间的任务
+func (m *Model) visualRange() []string {
+	if m.visualAnchor == "" {
+		return nil
+	}
+	visible := m.visibleIndexes()
+	anchor := -1
+	for i, index := range visible {
+		if m.items[index].id == m.visualAnchor {
+			anchor = i
+			break
+		}
+	}
+	if anchor < 0 || m.cursor >= len(visible) {
+		return nil
+	}
+	from, to := anchor, m.cursor
+	if from > to {
+		from, to = to, from
+	}
+	ids := make([]string, 0, to-from+1)
+	for _, index := range visible[from : to+1] {
+		ids = append(ids, m.items[index].id)
+	}
+	return ids
+}
+
+func (m *Model) isMarked(id string) bool {
+	if m.marked[id] {
+		return true
+	}
+	for _, rangeID := range m.visualRange() {
+		if rangeID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) hasMarks() bool {
+	return len(m.marked) > 0 || m.visualAnchor != ""
+}
+
+func (m *Model) clearMarks() {
+	m.marked = make(map[string]bool)
+	m.visualAnchor = ""
+}
+
+// 批量操作的对象：有标记时为全部标记的任务（按列表顺序），否则为光标所在任务
+func (m *Model) targetIDs() []string {
+	if !m.hasMarks() {
+		if index := m.currentIndex(); index >= 0 {
+			return []string{m.items[index].id}
+		}
+		return nil
+	}
+
+	selected := make(map[string]bool, len(m.marked))
+	for id := range m.marked {
+		selected[id] = true
+	}
+	for _, id := range m.visualRange() {
+		selected[id] = true
+	}
+	var ids []string
+	for _, item := range m.items {
+		if selected[item.id] {
+			ids = append(ids, item.id)
+		}
+	}
+	return ids
+}
+
+// ====================== 批量操作 ======================
+
+// 批量写入后清除标记
+func (m *Model) commitBulk(upserts []TodoItem, deleteIDs []string) bool {
+	if !m.applyChanges(upserts, deleteIDs) {
+		return false
+	}
+	m.clearMarks()
+	return true
+}
+
+// 在一个事务中写入修改和删除，成功后同步到列表并作为一步记入撤销历史。
+// 有任务已被其他地方修改时全部不写入，用户处理冲突后再重新写入
+func (m *Model) applyChanges(upserts []TodoItem, deleteIDs []string) bool {
+	if m.storage != nil {
+		if err := m.storage.Apply(upserts, deleteIDs); err != nil {
+			m.statusLine = err.Error()
+			m.queueApplyConflict(err, upserts, deleteIDs)
+			return false
+		}
+	}
+
+	removed := make(map[string]bool)
+	for _, id := range deleteIDs {
+		removed[id] = true
+		for _, childID := range m.items.descendantIDs(id) {
+			removed[childID] = true
+		}
+	}
+	kept := m.items[:0]
+	for _, item := range m.items {
+		if !removed[item.id] {
+			kept = append(kept, item)
+			continue
+		}
+		if before, ok := m.persisted[item.id]; ok {
+			m.history.record(&before, nil)
+			delete(m.persisted, item.id)
+		}
+	}
+	m.items = kept
+
+	for _, item := range upserts {
+		item.Revision++
+		if before, ok := m.persisted[item.id]; ok {
+			m.history.record(&before, &item)
+		} else {
+			m.history.record(nil, &item)
+		}
+		m.persisted[item.id] = item.clone()
+		if index := m.indexByID(item.id); index >= 0 {
+			m.items[index] = item
+		} else {
+			m.items = append(m.items, item)
+		}
+	}
+	m.sortItems()
+	m.findItemByID(m.selectedID)
+	return true
+}
+
+// 批量写入时的冲突：处理后用结果替换冲突的任务，其余修改原样重新写入
+func (m *Model) queueApplyConflict(err error, upserts []TodoItem, deleteIDs []string) {
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		return
+	}
+	index := slices.IndexFunc(upserts, func(item TodoItem) bool { return item.id == conflict.Theirs.id })
+	if index < 0 {
+		return
+	}
+	m.queueConflict(err, upserts[index], func(resolved *TodoItem) {
+		rest := slices.Delete(slices.Clone(upserts), index, index+1)
+		if resolved != nil {
+			rest = append(rest, *resolved)
+		}
+		if len(rest) > 0 || len(deleteIDs) > 0 {
+			m.applyChanges(rest, deleteIDs)
+		}
+	})
+}
+
+// 批量修改：对每个任务调用 apply，返回 false 的任务不写入
+func (m *Model) bulkUpdate(ids []string, apply func(item *TodoItem) bool) ([]TodoItem, int) {
+	var upserts []TodoItem
+	skipped := 0
+	for _, id := range ids {
+		index := m.indexByID(id)
+		if index < 0 {
+			continue
+		}
+		item := m.items[index].clone()
+		if !apply(&item) {
+			skipped++
+			continue
+		}
+		item.touch()
+		upserts = append(upserts, item)
+	}
+	return upserts, skipped
+}
+
+// 批量切换完成状态：有未完成的任务时全部标为完成，否则全部标为未完成
+func (m *Model) bulkToggleDone() {
+	ids := m.targetIDs()
+	done := false
+	for _, id := range ids {
+		if index := m.indexByID(id); index >= 0 && !m.items[index].Done {
+			done = true
+			break
+		}
+	}
+
+	var created []TodoItem
+	upserts, _ := m.bulkUpdate(ids, func(item *TodoItem) bool {
+		if item.Done == done {
+			return false
+		}
+		item.Done = done
+		// 完成重复任务时生成下一次任务，已完成的任务不再携带规则
+		if next, ok := nextOccurrence(*item); done && ok {
+			created = append(created, next)
+			item.Recurrence = Recurrence{}
+		}
+		return true
+	})
+	if len(upserts) == 0 {
+		return
+	}
+	if !m.commitBulk(append(upserts, created...), nil) {
+		return
+	}
+	if done {
+		m.statusLine = fmt.Sprintf("已完成 %d 个任务", len(upserts))
+	} else {
+		m.statusLine = fmt.Sprintf("已将 %d 个任务标为未完成", len(upserts))
+	}
+	if len(created) > 0 {
+		m.statusLine += fmt.Sprintf("，生成 %d 个重复任务", len(created))
+	}
+}
+
+// 批量移入回收站
+func (m *Model) bulkDelete() {
+	ids := m.targetIDs()
+	if len(ids) == 0 {
+		return
+	}
+	if m.commitBulk(nil, ids) {
+		m.statusLine = fmt.Sprintf("已将 %d 个任务移入回收站，u 撤销", len(ids))
+	}
+}
+
+func (m *Model) startBulkPriority() {
+	index := m.currentIndex()
+	if index < 0 {
+		return
+	}
+	m.startPriorityPicker(InputContextBulkPriority, m.items[index].Priority)
+	m.statusLine = fmt.Sprintf("为 %d 个任务设置优先级 • ↑/↓ 选择 • Enter 确认 • Esc 取消", len(m.targetIDs()))
+}
+
+func (m *Model) confirmBulkPriority(priority Priority) {
+	upserts, _ := m.bulkUpdate(m.targetIDs(), func(item *TodoItem) bool {
+		if item.Priority == priority {
+			return false
+		}
+		item.Priority = priority
+		return true
+	})
+	if len(upserts) == 0 {
+		m.clearMarks()
+		return
+	}
+	if m.commitBulk(upserts, nil) {
+		m.statusLine = fmt.Sprintf("已将 %d 个任务的优先级设为 %s", len(upserts), priority)
+	}
+}
+
+func (m *Model) startBulkInput(context InputContext, placeholder string) tea.Cmd {
+	if len(m.targetIDs()) == 0 {
+		return nil
+	}
+	m.mode = ModeInputTitle
+	m.inputContext = context
+	m.input.SetValue("")
+	m.input.Placeholder = placeholder
+	m.statusLine = fmt.Sprintf("作用于 %d 个任务 • Enter 确认 • Esc 取消", len(m.targetIDs()))
+	return m.input.Focus()
+}
+
+// 截止日期整体顺延 N 天，N 可以为负数；没有截止日期的任务跳过
+func (m *Model) confirmShiftDeadline(value string) bool {
+	days, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+	if err != nil || days == 0 {
+		m.statusLine = "请输入非零的天数，例如 3 或 -1"
+		return false
+	}
+
+	upserts, skipped := m.bulkUpdate(m.targetIDs(), func(item *TodoItem) bool {
+		if !item.HasDeadline {
+			return false
+		}
+		item.Deadline = item.Deadline.AddDate(0, 0, days)
+		return true
+	})
+	if len(upserts) > 0 && !m.commitBulk(upserts, nil) {
+		return true
+	}
+	m.clearMarks()
+	m.statusLine = fmt.Sprintf("已将 %d 个任务的截止日期顺延 %+d 天", len(upserts), days)
+	if skipped > 0 {
+		m.statusLine += fmt.Sprintf("，%d 个没有截止日期的任务已跳过", skipped)
+	}
+	return true
+}
+
+// 为全部目标任务追加标签
+func (m *Model) confirmBulkTag(value string) bool {
+	tags := parseTagList(value)
+	if len(tags) == 0 {
+		m.statusLine = "标签不能为空"
+		return false
+	}
+
+	upserts, _ := m.bulkUpdate(m.targetIDs(), func(item *TodoItem) bool {
+		before := len(item.Tags)
+		for _, tag := range tags {
+			item.Tags = appendTag(item.Tags, tag)
+		}
+		return len(item.Tags) != before
+	})
+	if len(upserts) > 0 && !m.commitBulk(upserts, nil) {
+		return true
+	}
+	m.clearMarks()
+	m.statusLine = fmt.Sprintf("已为 %d 个任务添加标签 %s", len(upserts), formatTags(tags))
+	return true
+}
@@ -0,0 +1,916 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kakkk/todo_cli/dateparse"
+)
+
+// ====================== 命令行子命令 ======================
+
+type cliCommand struct {
+	name  string
+	usage string
+	run   func(storage TaskStore, args []string) error
+}
+
+var cliCommands = []cliCommand{
+	{"add", "add <标题 #标签...> [--priority P0|P1|P2] [--due 时间] [--project 项目] [--parent 父任务id] [--notes 备注] [重复参数]", cliAdd},
+	{"list", "list [--pending] [--tag 标签,...] [--project 项目] [--sort 排序方式]", cliList},
+	{"show", "show <id> [--project 项目]（显示详情和备注）", cliShow},
+	{"done", "done <id>... [--project 项目]", cliDone},
+	{"edit", "edit <id> [--title 标题] [--priority P0|P1|P2] [--due 时间 | --no-due] [--tags 标签,...] [--notes 备注] [--move 项目] [--project 项目] [重复参数]", cliEdit},
+	{"rm", "rm <id>... [--project 项目]（移入回收站，子任务一并移入）", cliRemove},
+	{"trash", "trash [list | restore <id>... | purge <id>... | empty]", cliTrash},
+	{"export", "export [--format json|csv|md|todotxt|ics] [--output 文件] [--project 项目] [--pending] [--events]", cliExport},
+	{"import", "import <文件> [--format json|csv|md|todotxt|ics] [--project 项目] [--dry-run]（跳过 id 或标题和截止日期相同的任务）", cliImport},
+	{"serve", "serve [--addr 127.0.0.1:7878] [--token 令牌]（启动本地 HTTP 接口，说明见 /openapi.json）", cliServe},
+	{"projects", "projects [list [--all] | add <名称> [--color 62] | archive <名称> | unarchive <名称>]", cliProjects},
+}
+
+// 执行子命令，返回进程退出码
+func runCLI(args []string) int {
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printCLIUsage(os.Stdout)
+		return 0
+	}
+
+	var command *cliCommand
+	for i := range cliCommands {
+		if cliCommands[i].name == name {
+			command = &cliCommands[i]
+			break
+		}
+	}
+	if command == nil {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", name)
+		printCLIUsage(os.Stderr)
+		return 2
+	}
+
+	storage, err := OpenStore()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "存储初始化失败: %v\n", err)
+		return 1
+	}
+	defer storage.Close()
+
+	if err := command.run(storage, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", command.name, err)
+		return 1
+	}
+	return 0
+}
+
+func printCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: todo_cli [命令] [参数]")
+	fmt.Fprintln(w, "不带命令运行时进入交互界面。")
+	fmt.Fprintln(w, "\n命令:")
+	for _, command := range cliCommands {
+		fmt.Fprintln(w, "  "+command.usage)
+	}
+	fmt.Fprintln(w, "\n时间写法:")
+	fmt.Fprintln(w, "  2006-01-02T15:04、2006-01-02、tomorrow 9am、fri、in 3 days、next month、明天下午3点、下周五、3天后")
+	fmt.Fprintln(w, "\n重复参数（需要截止日期）:")
+	fmt.Fprintln(w, "  --repeat none|daily|weekly|monthly|yearly  --every N  --on mon,wed  --until 2006-01-02")
+}
+
+// 重复规则相关的参数，add 和 edit 共用
+type recurrenceFlags struct {
+	repeat *string
+	every  *int
+	on     *string
+	until  *string
+}
+
+func addRecurrenceFlags(fs *flag.FlagSet) recurrenceFlags {
+	return recurrenceFlags{
+		repeat: fs.String("repeat", "", "重复频率 none/daily/weekly/monthly/yearly"),
+		every:  fs.Int("every", 1, "重复间隔"),
+		on:     fs.String("on", "", "每周重复的星期，例如 mon,wed"),
+		until:  fs.String("until", "", "最后一次重复的日期"),
+	}
+}
+
+// 在已有规则的基础上应用显式指定的参数
+func (f recurrenceFlags) apply(rule Recurrence, set map[string]bool) (Recurrence, error) {
+	var err error
+	if set["repeat"] {
+		if rule.Frequency, err = ParseFrequency(*f.repeat); err != nil {
+			return rule, err
+		}
+	}
+	if set["every"] {
+		if *f.every < 1 {
+			return rule, errors.New("重复间隔至少为 1")
+		}
+		rule.Interval = *f.every
+	}
+	if set["on"] {
+		if rule.Weekdays, err = parseWeekdays(*f.on); err != nil {
+			return rule, err
+		}
+	}
+	if set["until"] {
+		rule.Until = time.Time{}
+		if *f.until != "" {
+			if rule.Until, err = parseDue(*f.until); err != nil {
+				return rule, err
+			}
+		}
+	}
+	if !rule.Enabled() {
+		return Recurrence{}, nil
+	}
+	if rule.Interval < 1 {
+		rule.Interval = 1
+	}
+	return rule, nil
+}
+
+// 已设置的参数名
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// 解析参数，允许标志与位置参数交错出现，例如 add "标题" --priority P0
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func cliAdd(storage TaskStore, args []string) error {
+	fs := newFlagSet("add")
+	priority := fs.String("priority", "P1", "优先级 P0/P1/P2")
+	due := fs.String("due", "", "截止日期，例如 2006-01-02T15:04、明天下午3点、fri 9am")
+	project := fs.String("project", "", "所属项目，不存在时自动创建")
+	parent := fs.String("parent", "", "父任务 id，作为子任务添加")
+	notes := fs.String("notes", "", "备注，支持 Markdown，- 表示从标准输入读取")
+	repeat := addRecurrenceFlags(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	title, tags := parseTitleTags(strings.Join(positional, " "))
+	if title == "" {
+		return errors.New("标题不能为空")
+	}
+
+	item := TodoItem{Title: title, Tags: tags, id: generateID()}
+	if item.Priority, err = ParsePriority(*priority); err != nil {
+		return err
+	}
+	if item.Notes, err = readNotes(*notes); err != nil {
+		return err
+	}
+	if *due != "" {
+		if item.Deadline, err = parseDue(*due); err != nil {
+			return err
+		}
+		item.HasDeadline = true
+	}
+	if item.Recurrence, err = repeat.apply(Recurrence{}, setFlags(fs)); err != nil {
+		return err
+	}
+	if item.Recurrence.Enabled() && !item.HasDeadline {
+		return errors.New("重复任务需要指定 --due")
+	}
+	if item.ProjectID, err = resolveProject(storage, *project, true); err != nil {
+		return err
+	}
+	if item.ProjectID == allProjectsID {
+		item.ProjectID = inboxProjectID
+	}
+	if *parent != "" {
+		items, err := storage.Load()
+		if err != nil {
+			return err
+		}
+		index, err := findByIDPrefix(items, *parent)
+		if err != nil {
+			return err
+		}
+		item.ParentID = items[index].id
+		// 未指定项目时跟随父任务
+		if *project == "" {
+			item.ProjectID = items[index].ProjectID
+		}
+	}
+
+	item.touch()
+	if err := storage.Create(item); err != nil {
+		return err
+	}
+
+	fmt.Printf("已添加 %s %s\n", shortID(item.id), item.Title)
+	return nil
+}
+
+func cliList(storage TaskStore, args []string) error {
+	fs := newFlagSet("list")
+	pending := fs.Bool("pending", false, "只显示未完成的任务")
+	tag := fs.String("tag", "", "只显示包含这些标签的任务，多个用逗号分隔")
+	project := fs.String("project", "", "只显示该项目的任务")
+	sortName := fs.String("sort", "", "排序方式 priority/deadline/created/updated/manual/title，默认沿用界面中的选择")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	mode := config.Sort
+	if *sortName != "" {
+		if mode, err = ParseSortMode(*sortName); err != nil {
+			return err
+		}
+	}
+
+	items, projects, err := loadItems(storage, *project)
+	if err != nil {
+		return err
+	}
+	items.SortBy(mode)
+
+	depths := items.depths()
+	for _, item := range items {
+		if *pending && item.Done {
+			continue
+		}
+		if !hasAllTags(item.Tags, parseTagList(*tag)) {
+			continue
+		}
+		// 子任务按层级缩进标题
+		item.Title = strings.Repeat("  ", depths[item.id]) + item.Title
+		fmt.Println(formatCLIItem(item, projects))
+	}
+	return nil
+}
+
+func cliShow(storage TaskStore, args []string) error {
+	fs := newFlagSet("show")
+	project := fs.String("project", "", "只在该项目中查找任务")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("需要且只能指定一个任务 id")
+	}
+
+	items, projects, err := loadItems(storage, *project)
+	if err != nil {
+		return err
+	}
+	index, err := findByIDPrefix(items, positional[0])
+	if err != nil {
+		return err
+	}
+	item := items[index]
+
+	status := "未完成"
+	if item.Done {
+		status = "已完成"
+	}
+	fmt.Printf("%s（%s）\n", item.Title, status)
+	for _, field := range detailFields(item, projects) {
+		fmt.Printf("  %s: %s\n", field[0], field[1])
+	}
+	if item.Notes != "" {
+		fmt.Printf("\n%s\n", item.Notes)
+	}
+	return nil
+}
+
+// 读取 --notes 参数，- 表示从标准输入读取
+func readNotes(value string) (string, error) {
+	if value != "-" {
+		return normalizeNotes(value), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("读取标准输入失败: %v", err)
+	}
+	return normalizeNotes(string(data)), nil
+}
+
+func cliDone(storage TaskStore, args []string) error {
+	fs := newFlagSet("done")
+	project := fs.String("project", "", "只在该项目中查找任务")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// 完成重复任务时生成下一次任务
+	var next []TodoItem
+	err = updateItems(storage, positional, *project, "完成", func(item *TodoItem) error {
+		if !item.Done {
+			if occurrence, ok := nextOccurrence(*item); ok {
+				next = append(next, occurrence)
+				item.Recurrence = Recurrence{}
+			}
+		}
+		item.Done = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range next {
+		if err := storage.Create(item); err != nil {
+			return err
+		}
+		fmt.Printf("已生成下一次任务 %s %s，截止 %s\n", shortID(item.id), item.Title, item.DeadlineString())
+	}
+	return nil
+}
+
+func cliEdit(storage TaskStore, args []string) error {
+	fs := newFlagSet("edit")
+	title := fs.String("title", "", "新的标题")
+	priority := fs.String("priority", "", "优先级 P0/P1/P2")
+	due := fs.String("due", "", "截止日期，例如 2006-01-02T15:04、明天下午3点、fri 9am")
+	noDue := fs.Bool("no-due", false, "清除截止日期及重复规则")
+	tags := fs.String("tags", "", "替换全部标签，多个用逗号分隔，留空清除")
+	notes := fs.String("notes", "", "替换备注，- 表示从标准输入读取，留空清除")
+	move := fs.String("move", "", "移动到指定项目，不存在时自动创建")
+	project := fs.String("project", "", "只在该项目中查找任务")
+	repeat := addRecurrenceFlags(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("需要且只能指定一个任务 id")
+	}
+
+	// 只修改显式指定的字段
+	set := setFlags(fs)
+	delete(set, "project")
+	if len(set) == 0 {
+		return errors.New("没有需要修改的字段")
+	}
+
+	if set["due"] && *noDue {
+		return errors.New("--due 与 --no-due 不能同时使用")
+	}
+
+	var newPriority Priority
+	if set["priority"] {
+		if newPriority, err = ParsePriority(*priority); err != nil {
+			return err
+		}
+	}
+	var newDeadline time.Time
+	if set["due"] {
+		if newDeadline, err = parseDue(*due); err != nil {
+			return err
+		}
+	}
+	// 标题中的 #标签 会追加到任务上
+	newTitle, titleTags := parseTitleTags(*title)
+	if set["title"] && newTitle == "" {
+		return errors.New("标题不能为空")
+	}
+	var newNotes string
+	if set["notes"] {
+		if newNotes, err = readNotes(*notes); err != nil {
+			return err
+		}
+	}
+	var newProjectID string
+	if set["move"] {
+		if newProjectID, err = resolveProject(storage, *move, true); err != nil {
+			return err
+		}
+		if newProjectID == allProjectsID {
+			newProjectID = inboxProjectID
+		}
+	}
+
+	// 先校验重复参数，出错时不写入任何修改
+	if _, err := repeat.apply(Recurrence{}, set); err != nil {
+		return err
+	}
+
+	return updateItems(storage, positional, *project, "更新", func(item *TodoItem) error {
+		if set["tags"] {
+			item.Tags = parseTagList(*tags)
+		}
+		if set["title"] {
+			item.Title = newTitle
+			for _, tag := range titleTags {
+				item.Tags = appendTag(item.Tags, tag)
+			}
+		}
+		if set["priority"] {
+			item.Priority = newPriority
+		}
+		if set["notes"] {
+			item.Notes = newNotes
+		}
+		if set["due"] {
+			item.HasDeadline = true
+			item.Deadline = newDeadline
+		}
+		if set["move"] {
+			item.ProjectID = newProjectID
+		}
+		if item.Recurrence, err = repeat.apply(item.Recurrence, set); err != nil {
+			return err
+		}
+		// 重复规则依赖截止日期，清除截止日期时一并清除
+		if *noDue {
+			item.HasDeadline = false
+			item.Deadline = time.Time{}
+			item.Recurrence = Recurrence{}
+		}
+		if item.Recurrence.Enabled() && !item.HasDeadline {
+			return fmt.Errorf("任务 %s 没有截止日期，重复任务需要指定 --due", shortID(item.id))
+		}
+		return nil
+	})
+}
+
+func cliExport(storage TaskStore, args []string) error {
+	fs := newFlagSet("export")
+	formatName := fs.String("format", "", "导出格式 json/csv/md/todotxt/ics，默认根据 --output 的扩展名判断，输出到终端时为 md")
+	output := fs.String("output", "", "输出文件，默认输出到终端")
+	project := fs.String("project", "", "只导出该项目的任务")
+	pending := fs.Bool("pending", false, "只导出未完成的任务")
+	events := fs.Bool("events", false, "导出 ics 时为有截止日期的未完成任务附加日历提醒事件")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("多余的参数 %s", strings.Join(positional, " "))
+	}
+
+	name := *formatName
+	if name == "" && *output == "" {
+		name = "md"
+	}
+	format, err := findFileFormat(name, *output)
+	if err != nil {
+		return err
+	}
+	if *events {
+		if format.name != "ics" {
+			return errors.New("--events 只能用于 ics 格式")
+		}
+		format.write = func(w io.Writer, items TodoList, projects []Project) error {
+			return writeICalendar(w, items, projects, true)
+		}
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	items, projects, err := loadItems(storage, *project)
+	if err != nil {
+		return err
+	}
+	items.SortBy(config.Sort)
+	if *pending {
+		kept := items[:0]
+		for _, item := range items {
+			if !item.Done {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+
+	if *output == "" {
+		return format.write(os.Stdout, items, projects)
+	}
+	path := expandHome(*output)
+	if err := exportToFile(path, format, items, projects); err != nil {
+		return err
+	}
+	fmt.Printf("已导出 %d 个任务到 %s\n", len(items), path)
+	return nil
+}
+
+func cliImport(storage TaskStore, args []string) error {
+	fs := newFlagSet("import")
+	formatName := fs.String("format", "", "文件格式 json/csv/md/todotxt/ics，默认根据扩展名判断")
+	project := fs.String("project", "", "文件中没有指定项目的任务导入到该项目，默认收件箱")
+	dryRun := fs.Bool("dry-run", false, "只显示将要导入的任务，不写入")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("需要指定一个导入文件")
+	}
+
+	path := expandHome(positional[0])
+	tasks, err := readImportFile(path, *formatName)
+	if err != nil {
+		return err
+	}
+	items, projects, err := loadItems(storage, "")
+	if err != nil {
+		return err
+	}
+	trashed, err := storage.LoadTrash()
+	if err != nil {
+		return err
+	}
+	planImport(tasks, items, trashed)
+
+	for _, task := range tasks {
+		mark := "[新]  "
+		if task.duplicate {
+			mark = "[重复]"
+		}
+		fmt.Println(mark + " " + formatCLIItem(task.item, projects))
+	}
+	duplicates := countDuplicates(tasks)
+	if *dryRun {
+		fmt.Printf("将导入 %d 个任务，跳过 %d 个重复任务\n", len(tasks)-duplicates, duplicates)
+		return nil
+	}
+
+	defaultProject, err := resolveProject(storage, *project, true)
+	if err != nil {
+		return err
+	}
+	if defaultProject == allProjectsID {
+		defaultProject = inboxProjectID
+	}
+	upserts, err := importUpserts(tasks, defaultProject, func(name string) (string, error) {
+		return resolveProject(storage, name, true)
+	})
+	if err != nil {
+		return err
+	}
+	if err := storage.Apply(upserts, nil); err != nil {
+		return err
+	}
+	fmt.Printf("已导入 %d 个任务，跳过 %d 个重复任务\n", len(upserts), duplicates)
+	return nil
+}
+
+func cliRemove(storage TaskStore, args []string) error {
+	fs := newFlagSet("rm")
+	project := fs.String("project", "", "只在该项目中查找任务")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return errors.New("需要指定任务 id")
+	}
+
+	items, _, err := loadItems(storage, *project)
+	if err != nil {
+		return err
+	}
+
+	// 先解析全部 id，避免删到一半才发现参数有误
+	var removed []TodoItem
+	for _, prefix := range positional {
+		index, err := findByIDPrefix(items, prefix)
+		if err != nil {
+			return err
+		}
+		removed = append(removed, items[index])
+	}
+
+	for _, item := range removed {
+		if err := storage.Delete(item.id); err != nil {
+			return err
+		}
+		fmt.Printf("已移入回收站 %s %s\n", shortID(item.id), item.Title)
+	}
+	return nil
+}
+
+// 按 id 前缀找到任务并修改，逐条写回。
+// apply 对任何一个任务返回错误时不写入任何修改
+func updateItems(storage TaskStore, ids []string, project, action string, apply func(item *TodoItem) error) error {
+	if len(ids) == 0 {
+		return errors.New("需要指定任务 id")
+	}
+
+	items, projects, err := loadItems(storage, project)
+	if err != nil {
+		return err
+	}
+
+	var indexes []int
+	for _, prefix := range ids {
+		index, err := findByIDPrefix(items, prefix)
+		if err != nil {
+			return err
+		}
+		indexes = append(indexes, index)
+	}
+
+	for _, index := range indexes {
+		if err := apply(&items[index]); err != nil {
+			return err
+		}
+		items[index].touch()
+	}
+	for _, index := range indexes {
+		if err := storage.Update(items[index]); err != nil {
+			return err
+		}
+		fmt.Printf("已%s %s\n", action, formatCLIItem(items[index], projects))
+	}
+	return nil
+}
+
+// 根据 id 前缀查找任务，前缀必须唯一
+func findByIDPrefix(items TodoList, prefix string) (int, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return -1, errors.New("任务 id 不能为空")
+	}
+
+	// 导入的任务沿用文件中的 id（如 ICS 的 UID），可能含大写字母，前缀不区分大小写
+	lower := strings.ToLower(prefix)
+	found := -1
+	for i, item := range items {
+		if item.id == prefix {
+			return i, nil
+		}
+		if strings.HasPrefix(strings.ToLower(item.id), lower) {
+			if found >= 0 {
+				return -1, fmt.Errorf("id %s 匹配到多个任务，请输入更长的前缀", prefix)
+			}
+			found = i
+		}
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("未找到任务 %s", prefix)
+	}
+	return found, nil
+}
+
+func cliProjects(storage TaskStore, args []string) error {
+	fs := newFlagSet("projects")
+	all := fs.Bool("all", false, "同时列出已归档的项目")
+	color := fs.String("color", "", "项目颜色（终端 256 色编号）")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "list"
+	if len(positional) > 0 {
+		action = positional[0]
+		positional = positional[1:]
+	}
+	name := strings.TrimSpace(strings.Join(positional, " "))
+
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		items, err := storage.Load()
+		if err != nil {
+			return err
+		}
+		done := make(map[string]int)
+		total := make(map[string]int)
+		for _, item := range items {
+			total[item.ProjectID]++
+			if item.Done {
+				done[item.ProjectID]++
+			}
+		}
+		fmt.Printf("%-20s %d/%d\n", inboxProjectName, done[inboxProjectID], total[inboxProjectID])
+		for _, project := range projects {
+			if project.Archived && !*all {
+				continue
+			}
+			line := fmt.Sprintf("%-20s %d/%d", project.Name, done[project.id], total[project.id])
+			if project.Archived {
+				line += "  (已归档)"
+			}
+			fmt.Println(line)
+		}
+		return nil
+	case "add":
+		if name == "" {
+			return errors.New("项目名称不能为空")
+		}
+		if isInboxName(name) || findProjectByName(projects, name) >= 0 {
+			return fmt.Errorf("项目 %s 已存在", name)
+		}
+		project := newProject(name, projects)
+		if *color != "" {
+			project.Color = *color
+		}
+		if err := storage.SaveProject(project); err != nil {
+			return err
+		}
+		fmt.Printf("已创建项目 %s\n", project.Name)
+		return nil
+	case "archive", "unarchive":
+		index := findProjectByName(projects, name)
+		if index < 0 {
+			return fmt.Errorf("项目 %s 不存在", name)
+		}
+		project := projects[index]
+		project.Archived = action == "archive"
+		if err := storage.SaveProject(project); err != nil {
+			return err
+		}
+		if project.Archived {
+			fmt.Printf("已归档项目 %s\n", project.Name)
+		} else {
+			fmt.Printf("已取消归档项目 %s\n", project.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知操作 %s，可选 list/add/archive/unarchive", action)
+	}
+}
+
+func cliTrash(storage TaskStore, args []string) error {
+	fs := newFlagSet("trash")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	action := "list"
+	if len(positional) > 0 {
+		action = positional[0]
+		positional = positional[1:]
+	}
+
+	items, err := storage.LoadTrash()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		for _, item := range items {
+			fmt.Printf("%s  %s  %s\n", shortID(item.id), item.DeletedAt.Format("2006-01-02 15:04"), item.Title)
+		}
+		return nil
+	case "restore", "purge":
+		if len(positional) == 0 {
+			return errors.New("需要指定任务 id")
+		}
+		// 先解析全部 id，避免处理到一半才发现参数有误
+		var selected []TodoItem
+		for _, prefix := range positional {
+			index, err := findByIDPrefix(items, prefix)
+			if err != nil {
+				return err
+			}
+			selected = append(selected, items[index])
+		}
+		for _, item := range selected {
+			if action == "restore" {
+				if err := storage.Restore(item.id); err != nil {
+					return err
+				}
+				fmt.Printf("已恢复 %s %s\n", shortID(item.id), item.Title)
+				continue
+			}
+			if err := storage.Purge(item.id); err != nil {
+				return err
+			}
+			fmt.Printf("已彻底删除 %s %s\n", shortID(item.id), item.Title)
+		}
+		return nil
+	case "empty":
+		count, err := storage.PurgeTrash(time.Now().Add(time.Second))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已清空回收站，共 %d 个任务\n", count)
+		return nil
+	default:
+		return fmt.Errorf("未知操作 %s，可选 list/restore/purge/empty", action)
+	}
+}
+
+// 把 --project 参数解析为项目 id，为空时返回 allProjectsID
+func resolveProject(storage TaskStore, name string, create bool) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return allProjectsID, nil
+	}
+	if isInboxName(name) {
+		return inboxProjectID, nil
+	}
+
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return "", err
+	}
+	if index := findProjectByName(projects, name); index >= 0 {
+		return projects[index].id, nil
+	}
+	if !create {
+		return "", fmt.Errorf("项目 %s 不存在", name)
+	}
+
+	project := newProject(name, projects)
+	if err := storage.SaveProject(project); err != nil {
+		return "", err
+	}
+	return project.id, nil
+}
+
+// 加载任务，并按 --project 参数只保留对应项目的任务
+func loadItems(storage TaskStore, project string) (TodoList, []Project, error) {
+	projectID, err := resolveProject(storage, project, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	projects, err := storage.LoadProjects()
+	if err != nil {
+		return nil, nil, err
+	}
+	items, err := storage.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	if projectID == allProjectsID {
+		return items, projects, nil
+	}
+
+	filtered := make(TodoList, 0, len(items))
+	for _, item := range items {
+		if item.ProjectID == projectID {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, projects, nil
+}
+
+func formatCLIItem(item TodoItem, projects []Project) string {
+	status := "[ ]"
+	if item.Done {
+		status = "[x]"
+	}
+	line := fmt.Sprintf("%s  %s %s  %-16s  %s",
+		shortID(item.id), status, item.Priority, item.DeadlineString(), item.Title)
+	if len(item.Tags) > 0 {
+		line += "  " + formatTags(item.Tags)
+	}
+	if item.ProjectID != inboxProjectID {
+		line += "  +" + projectName(projects, item.ProjectID)
+	}
+	return line
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// 解析截止日期，与交互界面的文字输入一致，支持 "明天下午3点"、"fri 9am" 等写法
+func parseDue(value string) (time.Time, error) {
+	return dateparse.Parse(value, time.Now())
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ====================== 配置 ======================
+
+// 用户配置，保存在 ~/.todo_cli/config.json，文件中没有的字段使用默认值
+type Config struct {
+	// 回收站中的任务保留天数，过期后自动彻底删除，0 表示一直保留
+	TrashRetentionDays int `json:"trash_retention_days"`
+	// 任务列表的排序方式，在界面中按 s 切换时自动保存
+	Sort SortMode `json:"sort"`
+}
+
+func defaultConfig() Config {
+	return Config{TrashRetentionDays: 30}
+}
+
+// 配置文件路径，可以通过 TODO_CLI_CONFIG 指定
+func configPath() string {
+	if path := os.Getenv("TODO_CLI_CONFIG"); path != "" {
+		return path
+	}
+	return dataFile("config.json")
+}
+
+func LoadConfig() (Config, error) {
+	config := defaultConfig()
+	path := configPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("读取配置 %s 失败: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("解析配置 %s 失败: %v", path, err)
+	}
+	if config.TrashRetentionDays < 0 {
+		return config, fmt.Errorf("配置 %s 中 trash_retention_days 不能为负数", path)
+	}
+	return config, nil
+}
+
+// 保存配置，合并进文件中现有的 JSON 对象，不认识的字段原样保留
+func SaveConfig(config Config) error {
+	path := configPath()
+	fields := make(map[string]json.RawMessage)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("读取配置 %s 失败: %v", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("解析配置 %s 失败: %v", path, err)
+		}
+	}
+
+	// 先转成对象再逐个覆盖，字段名沿用 Config 的 json 标签
+	data, err = json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+	var current map[string]json.RawMessage
+	if err := json.Unmarshal(data, &current); err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+	for key, value := range current {
+		fields[key] = value
+	}
+
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ====================== 写入冲突 ======================
+
+// 一次因修订号不符而未能写入的修改
+type writeConflict struct {
+	base   TodoItem // 修改前界面中的内容
+	mine   TodoItem // 想要写入的内容
+	theirs TodoItem // 存储中的当前内容
+	// 用处理结果重新写入；resolved 为 nil 表示放弃这个任务的修改
+	retry func(resolved *TodoItem)
+}
+
+// 任务中可以单独合并的字段
+type taskField struct {
+	name  string
+	equal func(a, b TodoItem) bool
+	copy  func(dst *TodoItem, src TodoItem)
+}
+
+var taskFields = []taskField{
+	{"标题", func(a, b TodoItem) bool { return a.Title == b.Title },
+		func(dst *TodoItem, src TodoItem) { dst.Title = src.Title }},
+	{"备注", func(a, b TodoItem) bool { return a.Notes == b.Notes },
+		func(dst *TodoItem, src TodoItem) { dst.Notes = src.Notes }},
+	{"完成状态", func(a, b TodoItem) bool { return a.Done == b.Done },
+		func(dst *TodoItem, src TodoItem) { dst.Done = src.Done }},
+	{"优先级", func(a, b TodoItem) bool { return a.Priority == b.Priority },
+		func(dst *TodoItem, src TodoItem) { dst.Priority = src.Priority }},
+	{"截止日期", func(a, b TodoItem) bool { return a.HasDeadline == b.HasDeadline && a.Deadline.Equal(b.Deadline) },
+		func(dst *TodoItem, src TodoItem) { dst.HasDeadline, dst.Deadline = src.HasDeadline, src.Deadline }},
+	{"重复规则", func(a, b TodoItem) bool { return sameRecurrence(a.Recurrence, b.Recurrence) },
+		func(dst *TodoItem, src TodoItem) { dst.Recurrence = src.clone().Recurrence }},
+	{"标签", func(a, b TodoItem) bool { return sameTags(a.Tags, b.Tags) },
+		func(dst *TodoItem, src TodoItem) { dst.Tags = src.clone().Tags }},
+	{"项目", func(a, b TodoItem) bool { return a.ProjectID == b.ProjectID },
+		func(dst *TodoItem, src TodoItem) { dst.ProjectID = src.ProjectID }},
+	{"父任务", func(a, b TodoItem) bool { return a.ParentID == b.ParentID },
+		func(dst *TodoItem, src TodoItem) { dst.ParentID = src.ParentID }},
+	{"顺序", func(a, b TodoItem) bool { return a.Position == b.Position },
+		func(dst *TodoItem, src TodoItem) { dst.Position = src.Position }},
+}
+
+func sameRecurrence(a, b Recurrence) bool {
+	return a.Frequency == b.Frequency &&
+		a.Interval == b.Interval &&
+		slices.Equal(a.Weekdays, b.Weekdays) &&
+		a.Until.Equal(b.Until)
+}
+
+// 两个版本之间不同的字段名称
+func changedFields(a, b TodoItem) []string {
+	var names []string
+	for _, field := range taskFields {
+		if !field.equal(a, b) {
+			names = append(names, field.name)
+		}
+	}
+	return names
+}
+
+// 逐字段合并：我改过的字段用我的，其余用对方的；双方都改过的字段以我的为准。
+// 对方已删除时合并结果会把任务从回收站中恢复
+func mergeTask(base, mine, theirs TodoItem) TodoItem {
+	merged := theirs.clone()
+	merged.DeletedAt = time.Time{}
+	for _, field := range taskFields {
+		if !field.equal(mine, base) {
+			field.copy(&merged, mine)
+		}
+	}
+	return merged
+}
+
+// 写入失败时判断是否为冲突，是则加入待处理的冲突，结束本次按键后提示用户
+func (m *Model) queueConflict(err error, mine TodoItem, retry func(resolved *TodoItem)) {
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		return
+	}
+	base, ok := m.persisted[mine.id]
+	if !ok {
+		base = conflict.Theirs
+	}
+	m.conflict.queue = append(m.conflict.queue, writeConflict{
+		base:   base,
+		mine:   mine.clone(),
+		theirs: conflict.Theirs,
+		retry:  retry,
+	})
+}
+
+// 有待处理的冲突时进入冲突提示，处理完后回到原来的模式
+func (m *Model) promptConflicts() {
+	if len(m.conflict.queue) == 0 || m.mode == ModeConflict {
+		return
+	}
+	m.conflict.returnMode = m.mode
+	m.mode = ModeConflict
+	m.statusLine = ""
+}
+
+func (m *Model) handleConflictMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	current := m.conflict.queue[0]
+	var resolved *TodoItem
+	switch msg.String() {
+	case "m":
+		item := current.mine.clone()
+		item.Revision = current.theirs.Revision
+		resolved = &item
+	case "t", "esc":
+	case "f":
+		item := mergeTask(current.base, current.mine, current.theirs)
+		resolved = &item
+	default:
+		return m, nil
+	}
+
+	m.conflict.queue = m.conflict.queue[1:]
+	if len(m.conflict.queue) == 0 {
+		m.mode = m.conflict.returnMode
+	}
+	m.statusLine = ""
+	if resolved == nil {
+		m.acceptTheirs(current.theirs)
+	}
+	current.retry(resolved)
+	if m.statusLine == "" {
+		if resolved == nil {
+			m.statusLine = fmt.Sprintf("已使用其他地方对「%s」的修改", current.theirs.Title)
+		} else {
+			m.statusLine = fmt.Sprintf("已保存「%s」", resolved.Title)
+		}
+	}
+	return m, nil
+}
+
+// 放弃自己的修改，界面中改为存储中的内容
+func (m *Model) acceptTheirs(theirs TodoItem) {
+	index := m.indexByID(theirs.id)
+	switch {
+	case theirs.Deleted():
+		if index >= 0 {
+			m.items = slices.Delete(m.items, index, index+1)
+		}
+		delete(m.persisted, theirs.id)
+	case index >= 0:
+		m.items[index] = theirs.clone()
+		m.persisted[theirs.id] = theirs.clone()
+	default:
+		m.items = append(m.items, theirs.clone())
+		m.persisted[theirs.id] = theirs.clone()
+	}
+	m.sortItems()
+}
+
+func (m *Model) renderConflictPrompt() string {
+	current := m.conflict.queue[0]
+	var builder strings.Builder
+	if current.theirs.Deleted() {
+		// 保留我的修改会把任务从回收站中恢复
+		builder.WriteString(fmt.Sprintf("「%s」已被其他地方删除", current.theirs.Title))
+	} else {
+		builder.WriteString(fmt.Sprintf("「%s」已被其他地方修改", current.theirs.Title))
+		if fields := changedFields(current.base, current.theirs); len(fields) > 0 {
+			builder.WriteString("，对方改了" + strings.Join(fields, "、"))
+		}
+	}
+	if fields := changedFields(current.base, current.mine); len(fields) > 0 {
+		builder.WriteString("，你改了" + strings.Join(fields, "、"))
+	}
+	if rest := len(m.conflict.queue) - 1; rest > 0 {
+		builder.WriteString(fmt.Sprintf("（还有 %d 处冲突）", rest))
+	}
+	return "\n  " + m.styles.Status.Render(builder.String()) + "\n" +
+		m.styles.Help.Render("  m 保留我的 • t/Esc 使用对方的 • f 合并字段（都改过的字段用我的）") + "\n"
+}
@@ -0,0 +1,242 @@
+// Package dateparse 把自然语言描述的时间解析为具体时刻，
+// 支持 "tomorrow 9am"、"tomorrow at 5"、"fri"、"this fri"、"in 3 days"、"1 week"、
+// "next month"、"2026-11-01 14:30"
+// 以及 "明天下午3点"、"下周五"、"3天后" 等中文写法。
+package dateparse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 只给出日期时使用的默认时刻
+const (
+	DefaultHour   = 17
+	DefaultMinute = 0
+)
+
+// 解析过程中的中间状态
+type state struct {
+	now time.Time
+
+	date    time.Time // 当天零点
+	hasDate bool
+
+	hour, minute, second int
+	hasTime              bool
+
+	period string // am / pm / noon / night，修正小时数
+
+	offset    time.Duration // "in 3 hours" 这类相对当前时刻的偏移
+	hasOffset bool
+}
+
+// 一条解析规则：匹配输入开头的一段文本，并更新状态
+type rule struct {
+	pattern *regexp.Regexp
+	apply   func(s *state, match []string) error
+}
+
+// Parse 以 now 为基准解析 value。
+// 只给出日期时默认当天 17:00；只给出时刻且已经过去时顺延到明天；
+// 单独的星期表示接下来的那一天（不含今天），"next fri" / "下周五" 表示下周的那一天。
+func Parse(value string, now time.Time) (time.Time, error) {
+	input := normalize(value)
+	if input == "" {
+		return time.Time{}, fmt.Errorf("日期为空")
+	}
+
+	s := &state{now: now}
+	rest := input
+	for {
+		rest = trimSeparators(rest)
+		if rest == "" {
+			break
+		}
+		matched := false
+		for _, r := range rules {
+			match := r.pattern.FindStringSubmatch(rest)
+			if match == nil {
+				continue
+			}
+			if err := r.apply(s, match); err != nil {
+				return time.Time{}, fmt.Errorf("无法解析日期 %q：%v", value, err)
+			}
+			rest = rest[len(match[0]):]
+			matched = true
+			break
+		}
+		if !matched {
+			return time.Time{}, fmt.Errorf("无法解析日期 %q：不认识 %q", value, rest)
+		}
+	}
+
+	return s.resolve(value)
+}
+
+func normalize(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	// 全角字符换成半角，方便中文输入法下直接输入
+	value = strings.NewReplacer("：", ":", "，", " ", "／", "/", "－", "-").Replace(value)
+	// 2026-11-01t14:30 中的 t 视为分隔符
+	return isoSeparator.ReplaceAllString(value, "$1 $2")
+}
+
+var isoSeparator = regexp.MustCompile(`(\d)t(\d)`)
+
+var separators = regexp.MustCompile(`^(\s+|,|at\b|on\b|的)`)
+
+func trimSeparators(s string) string {
+	for {
+		loc := separators.FindStringIndex(s)
+		if loc == nil {
+			return s
+		}
+		s = s[loc[1]:]
+	}
+}
+
+// 把中间状态换算成最终时刻
+func (s *state) resolve(value string) (time.Time, error) {
+	if s.hasOffset {
+		if s.hasDate || s.hasTime || s.period != "" {
+			return time.Time{}, fmt.Errorf("无法解析日期 %q：相对时长不能与日期或时刻同时使用", value)
+		}
+		return s.now.Add(s.offset).Truncate(time.Minute), nil
+	}
+
+	hour, minute, second := DefaultHour, DefaultMinute, 0
+	if s.hasTime {
+		hour, minute, second = s.hour, s.minute, s.second
+		switch s.period {
+		case "am":
+			if hour == 12 {
+				hour = 0
+			}
+		case "pm", "evening", "night":
+			if hour < 12 {
+				hour += 12
+			}
+		case "noon":
+			// 中午 1 点指 13 点，中午 11 点仍是上午
+			if hour < 11 {
+				hour += 12
+			}
+		}
+	} else if s.period != "" {
+		hour, minute = periodDefaults[s.period], 0
+	}
+	if hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, fmt.Errorf("无法解析日期 %q：时刻超出范围", value)
+	}
+
+	date := s.date
+	if !s.hasDate {
+		date = startOfDay(s.now)
+	}
+	result := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, second, 0, s.now.Location())
+
+	// 只给出时刻且已经过去，理解为明天的这个时刻
+	if !s.hasDate && !result.After(s.now) {
+		result = result.AddDate(0, 0, 1)
+	}
+	return result, nil
+}
+
+// 只有时间段没有具体时刻时使用的默认时刻
+var periodDefaults = map[string]int{
+	"morning": 9,
+	"am":      9,
+	"noon":    12,
+	"pm":      15,
+	"evening": 20,
+	"night":   20,
+}
+
+func (s *state) setDate(date time.Time) error {
+	if s.hasDate {
+		return fmt.Errorf("日期重复指定")
+	}
+	s.date = startOfDay(date)
+	s.hasDate = true
+	return nil
+}
+
+func (s *state) setTime(hour, minute, second int) error {
+	if s.hasTime {
+		return fmt.Errorf("时刻重复指定")
+	}
+	s.hour, s.minute, s.second = hour, minute, second
+	s.hasTime = true
+	return nil
+}
+
+func (s *state) setPeriod(period string) error {
+	if s.period != "" {
+		return fmt.Errorf("时间段重复指定")
+	}
+	s.period = period
+	return nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// 按月偏移，日期超出目标月份天数时取月末，例如 1 月 31 日加一个月为 2 月 28 日
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// 接下来的某个星期几，不含今天
+func nextWeekday(now time.Time, day time.Weekday) time.Time {
+	diff := (int(day) - int(now.Weekday()) + 7) % 7
+	if diff == 0 {
+		diff = 7
+	}
+	return now.AddDate(0, 0, diff)
+}
+
+// 本周（周一开始）的某个星期几，可能已经过去
+func weekdayOfThisWeek(now time.Time, day time.Weekday) time.Time {
+	sinceMonday := (int(now.Weekday()) + 6) % 7
+	return now.AddDate(0, 0, (int(day)+6)%7-sinceMonday)
+}
+
+// 下周（周一开始）的某个星期几
+func weekdayOfNextWeek(now time.Time, day time.Weekday) time.Time {
+	sinceMonday := (int(now.Weekday()) + 6) % 7
+	nextMonday := now.AddDate(0, 0, 7-sinceMonday)
+	return nextMonday.AddDate(0, 0, (int(day)+6)%7)
+}
+
+// 按单位偏移：分钟和小时记为相对当前时刻的偏移，其余单位偏移日期
+func (s *state) shift(n int, unit string) error {
+	switch unit {
+	case "minute":
+		s.offset += time.Duration(n) * time.Minute
+		s.hasOffset = true
+		return nil
+	case "hour":
+		s.offset += time.Duration(n) * time.Hour
+		s.hasOffset = true
+		return nil
+	case "day":
+		return s.setDate(s.now.AddDate(0, 0, n))
+	case "week":
+		return s.setDate(s.now.AddDate(0, 0, 7*n))
+	case "month":
+		return s.setDate(addMonths(s.now, n))
+	case "year":
+		return s.setDate(addMonths(s.now, 12*n))
+	}
+	return fmt.Errorf("未知的时间单位 %q", unit)
+}
@@ -0,0 +1,106 @@
+package dateparse
+
+import (
+	"testing"
+	"time"
+)
+
+// 2026-10-14 是周三
+var now = time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2026, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		// 英文相对日期
+		{"today", at(10, 14, 17, 0)},
+		{"tomorrow", at(10, 15, 17, 0)},
+		{"tomorrow 9am", at(10, 15, 9, 0)},
+		{"tomorrow at 5", at(10, 15, 17, 0)},
+		{"tomorrow at 5pm", at(10, 15, 17, 0)},
+		{"tomorrow at 9am", at(10, 15, 9, 0)},
+		{"tomorrow at 14:30", at(10, 15, 14, 30)},
+		{"day after tomorrow", at(10, 16, 17, 0)},
+		{"tonight", at(10, 14, 20, 0)},
+		{"noon", at(10, 14, 12, 0)},
+		{"tomorrow morning", at(10, 15, 9, 0)},
+
+		// 星期
+		{"fri", at(10, 16, 17, 0)},
+		{"wed", at(10, 21, 17, 0)},
+		{"this fri", at(10, 16, 17, 0)},
+		{"this mon", at(10, 12, 17, 0)},
+		{"next fri", at(10, 23, 17, 0)},
+		{"friday 3pm", at(10, 16, 15, 0)},
+
+		// 时长
+		{"in 3 days", at(10, 17, 17, 0)},
+		{"1 week", at(10, 21, 17, 0)},
+		{"a week", at(10, 21, 17, 0)},
+		{"3 days later", at(10, 17, 17, 0)},
+		{"+2w", at(10, 28, 17, 0)},
+		{"in an hour", at(10, 14, 11, 0)},
+		{"2 hours", at(10, 14, 12, 0)},
+		{"next week", at(10, 21, 17, 0)},
+		{"next month", at(11, 14, 17, 0)},
+
+		// 绝对日期和时刻
+		{"2026-11-01", at(11, 1, 17, 0)},
+		{"2026-11-01 14:30", at(11, 1, 14, 30)},
+		{"2026-11-01T14:30", at(11, 1, 14, 30)},
+		{"11/1", at(11, 1, 17, 0)},
+		{"10/1", time.Date(2027, 10, 1, 17, 0, 0, 0, time.UTC)},
+		{"11am", at(10, 14, 11, 0)},
+		{"9am", at(10, 15, 9, 0)},
+		{"14:30", at(10, 14, 14, 30)},
+
+		// 中文
+		{"今天", at(10, 14, 17, 0)},
+		{"明天下午3点", at(10, 15, 15, 0)},
+		{"明天上午十点半", at(10, 15, 10, 30)},
+		{"后天", at(10, 16, 17, 0)},
+		{"今晚8点", at(10, 14, 20, 0)},
+		{"中午1点", at(10, 14, 13, 0)},
+		{"周五", at(10, 16, 17, 0)},
+		{"下周五", at(10, 23, 17, 0)},
+		{"本周一", at(10, 12, 17, 0)},
+		{"3天后", at(10, 17, 17, 0)},
+		{"两个小时后", at(10, 14, 12, 0)},
+		{"半小时后", at(10, 14, 10, 30)},
+		{"12月25日", at(12, 25, 17, 0)},
+		{"2026年11月1日", at(11, 1, 17, 0)},
+		{"下个月", at(11, 14, 17, 0)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input, now)
+		if err != nil {
+			t.Errorf("Parse(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v，应为 %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"blah",
+		"2026-02-30",
+		"25:00",
+		"tomorrow at 25",
+		"tomorrow tomorrow",
+		"in 3 hours tomorrow",
+		"next",
+	} {
+		if got, err := Parse(input, now); err == nil {
+			t.Errorf("Parse(%q) = %v，应返回错误", input, got)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package dateparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ====================== 词表 ======================
+
+var englishWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var chineseWeekdays = map[string]time.Weekday{
+	"一": time.Monday, "二": time.Tuesday, "三": time.Wednesday, "四": time.Thursday,
+	"五": time.Friday, "六": time.Saturday, "日": time.Sunday, "天": time.Sunday,
+}
+
+var englishUnits = map[string]string{
+	"m": "minute", "min": "minute", "mins": "minute", "minute": "minute", "minutes": "minute",
+	"h": "hour", "hr": "hour", "hrs": "hour", "hour": "hour", "hours": "hour",
+	"d": "day", "day": "day", "days": "day",
+	"w": "week", "wk": "week", "wks": "week", "week": "week", "weeks": "week",
+	"mo": "month", "month": "month", "months": "month",
+	"y": "year", "yr": "year", "yrs": "year", "year": "year", "years": "year",
+}
+
+var chineseUnits = map[string]string{
+	"分钟": "minute", "小时": "hour", "个小时": "hour", "钟头": "hour", "个钟头": "hour",
+	"天": "day", "日": "day",
+	"周": "week", "个周": "week", "星期": "week", "个星期": "week", "礼拜": "week", "个礼拜": "week",
+	"月": "month", "个月": "month", "年": "year",
+}
+
+var englishNumbers = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+}
+
+var chineseDigits = map[rune]int{
+	'零': 0, '〇': 0, '一': 1, '二': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// 解析阿拉伯数字或不超过九十九的中文数字
+func parseNumber(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	if n, ok := englishNumbers[s]; ok {
+		return n, nil
+	}
+
+	runes := []rune(s)
+	switch {
+	case len(runes) == 1 && runes[0] == '十':
+		return 10, nil
+	case len(runes) == 1:
+		if n, ok := chineseDigits[runes[0]]; ok {
+			return n, nil
+		}
+	case len(runes) == 2 && runes[0] == '十':
+		if n, ok := chineseDigits[runes[1]]; ok {
+			return 10 + n, nil
+		}
+	case len(runes) == 2 && runes[1] == '十':
+		if n, ok := chineseDigits[runes[0]]; ok {
+			return n * 10, nil
+		}
+	case len(runes) == 3 && runes[1] == '十':
+		tens, ok1 := chineseDigits[runes[0]]
+		ones, ok2 := chineseDigits[runes[2]]
+		if ok1 && ok2 {
+			return tens*10 + ones, nil
+		}
+	}
+	return 0, fmt.Errorf("无法识别数字 %q", s)
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func keys[V any](m map[string]V) string {
+	list := make([]string, 0, len(m))
+	for k := range m {
+		list = append(list, regexp.QuoteMeta(k))
+	}
+	// 长的写在前面，避免 "mon" 抢先匹配 "monday"
+	for i := 1; i < len(list); i++ {
+		for j := i; j > 0 && len(list[j]) > len(list[j-1]); j-- {
+			list[j], list[j-1] = list[j-1], list[j]
+		}
+	}
+	return strings.Join(list, "|")
+}
+
+// ====================== 规则 ======================
+
+const cnNumber = `[零〇一二两三四五六七八九十]{1,3}`
+
+// 按顺序尝试，先匹配到的规则生效
+var rules = []rule{
+	// 绝对日期：2026-11-01、2026/11/01、2026年11月1日
+	{regexp.MustCompile(`^(\d{4})[-/.年](\d{1,2})[-/.月](\d{1,2})[日号]?`), func(s *state, m []string) error {
+		return s.setAbsoluteDate(atoi(m[1]), atoi(m[2]), atoi(m[3]))
+	}},
+	// 省略年份：11-01、11/1、11月1日、十二月二十五日
+	{regexp.MustCompile(`^(\d{1,2})[-/](\d{1,2})`), func(s *state, m []string) error {
+		return s.setMonthDay(atoi(m[1]), atoi(m[2]))
+	}},
+	{regexp.MustCompile(`^(\d{1,2}|` + cnNumber + `)月(\d{1,2}|` + cnNumber + `)[日号]?`), func(s *state, m []string) error {
+		month, err := parseNumber(m[1])
+		if err != nil {
+			return err
+		}
+		day, err := parseNumber(m[2])
+		if err != nil {
+			return err
+		}
+		return s.setMonthDay(month, day)
+	}},
+	// 时刻：14:30、9:30:15、9am、9:30pm
+	{regexp.MustCompile(`^(\d{1,2}):(\d{2})(?::(\d{2}))?\s*(am|pm|a\.m\.|p\.m\.)?`), func(s *state, m []string) error {
+		if err := s.setTime(atoi(m[1]), atoi(m[2]), atoi(m[3])); err != nil {
+			return err
+		}
+		return s.setMeridiem(m[4])
+	}},
+	{regexp.MustCompile(`^(\d{1,2})\s*(am|pm|a\.m\.|p\.m\.)`), func(s *state, m []string) error {
+		if err := s.setTime(atoi(m[1]), 0, 0); err != nil {
+			return err
+		}
+		return s.setMeridiem(m[2])
+	}},
+	// 3 days later、in 3 days、in an hour、+2w、1 week
+	{regexp.MustCompile(`^(\d+)\s*(` + keys(englishUnits) + `)\s+(?:later|from now)\b`), func(s *state, m []string) error {
+		return s.shift(atoi(m[1]), englishUnits[m[2]])
+	}},
+	{regexp.MustCompile(`^(?:in\s+|\+)?(\d+|a|an|one|two|three|four|five|six|seven|eight|nine|ten)\s*(` + keys(englishUnits) + `)\b`), func(s *state, m []string) error {
+		n, err := parseNumber(m[1])
+		if err != nil {
+			return err
+		}
+		return s.shift(n, englishUnits[m[2]])
+	}},
+	// 英文相对日期
+	{regexp.MustCompile(`^day after tomorrow\b`), func(s *state, m []string) error {
+		return s.setDate(s.now.AddDate(0, 0, 2))
+	}},
+	{regexp.MustCompile(`^(today|tod)\b`), func(s *state, m []string) error {
+		return s.setDate(s.now)
+	}},
+	{regexp.MustCompile(`^tonight\b`), func(s *state, m []string) error {
+		if err := s.setDate(s.now); err != nil {
+			return err
+		}
+		return s.setPeriod("night")
+	}},
+	{regexp.MustCompile(`^(tomorrow|tmrw|tmr)\b`), func(s *state, m []string) error {
+		return s.setDate(s.now.AddDate(0, 0, 1))
+	}},
+	{regexp.MustCompile(`^next\s+(` + keys(englishWeekdays) + `)\b`), func(s *state, m []string) error {
+		return s.setDate(weekdayOfNextWeek(s.now, englishWeekdays[m[1]]))
+	}},
+	{regexp.MustCompile(`^next\s+(week|month|year)\b`), func(s *state, m []string) error {
+		return s.shift(1, m[1])
+	}},
+	{regexp.MustCompile(`^this\s+(` + keys(englishWeekdays) + `)\b`), func(s *state, m []string) error {
+		return s.setDate(weekdayOfThisWeek(s.now, englishWeekdays[m[1]]))
+	}},
+	{regexp.MustCompile(`^(` + keys(englishWeekdays) + `)\b`), func(s *state, m []string) error {
+		return s.setDate(nextWeekday(s.now, englishWeekdays[m[1]]))
+	}},
+	{regexp.MustCompile(`^(morning|afternoon|evening|noon)\b`), func(s *state, m []string) error {
+		period := map[string]string{"morning": "morning", "afternoon": "pm", "evening": "evening", "noon": "noon"}[m[1]]
+		if m[1] == "noon" && !s.hasTime {
+			// 单独的 noon 就是 12:00
+			return s.setTime(12, 0, 0)
+		}
+		return s.setPeriod(period)
+	}},
+
+	// 中文相对日期
+	{regexp.MustCompile(`^大后天`), func(s *state, m []string) error {
+		return s.setDate(s.now.AddDate(0, 0, 3))
+	}},
+	{regexp.MustCompile(`^后天`), func(s *state, m []string) error {
+		return s.setDate(s.now.AddDate(0, 0, 2))
+	}},
+	{regexp.MustCompile(`^(今天|今日)`), func(s *state, m []string) error {
+		return s.setDate(s.now)
+	}},
+	{regexp.MustCompile(`^(明天|明日)`), func(s *state, m []string) error {
+		return s.setDate(s.now.AddDate(0, 0, 1))
+	}},
+	{regexp.MustCompile(`^今晚`), func(s *state, m []string) error {
+		if err := s.setDate(s.now); err != nil {
+			return err
+		}
+		return s.setPeriod("night")
+	}},
+	{regexp.MustCompile(`^明晚`), func(s *state, m []string) error {
+		if err := s.setDate(s.now.AddDate(0, 0, 1)); err != nil {
+			return err
+		}
+		return s.setPeriod("night")
+	}},
+	{regexp.MustCompile(`^(下个?|本|这个?)?(?:周|星期|礼拜)([一二三四五六日天])`), func(s *state, m []string) error {
+		day := chineseWeekdays[m[2]]
+		if strings.HasPrefix(m[1], "下") {
+			return s.setDate(weekdayOfNextWeek(s.now, day))
+		}
+		if m[1] != "" {
+			return s.setDate(weekdayOfThisWeek(s.now, day))
+		}
+		return s.setDate(nextWeekday(s.now, day))
+	}},
+	{regexp.MustCompile(`^下个?(周|星期|礼拜)`), func(s *state, m []string) error {
+		return s.shift(1, "week")
+	}},
+	{regexp.MustCompile(`^下个?月`), func(s *state, m []string) error {
+		return s.shift(1, "month")
+	}},
+	{regexp.MustCompile(`^明年`), func(s *state, m []string) error {
+		return s.shift(1, "year")
+	}},
+	// 3天后、两个小时后、半小时后
+	{regexp.MustCompile(`^半个?(小时|钟头)[之以]?后`), func(s *state, m []string) error {
+		return s.shift(30, "minute")
+	}},
+	{regexp.MustCompile(`^(\d+|` + cnNumber + `)\s*(` + keys(chineseUnits) + `)[之以]?后`), func(s *state, m []string) error {
+		n, err := parseNumber(m[1])
+		if err != nil {
+			return err
+		}
+		return s.shift(n, chineseUnits[m[2]])
+	}},
+	// 中文时间段
+	{regexp.MustCompile(`^(凌晨|早上|早晨|上午|中午|下午|傍晚|晚上)`), func(s *state, m []string) error {
+		period := map[string]string{
+			"凌晨": "am", "早上": "morning", "早晨": "morning", "上午": "morning",
+			"中午": "noon", "下午": "pm", "傍晚": "pm", "晚上": "night",
+		}[m[1]]
+		return s.setPeriod(period)
+	}},
+	// 中文时刻：3点、3点半、15点30、三点一刻、9时
+	{regexp.MustCompile(`^(\d{1,2}|` + cnNumber + `)\s*[点时](半|一刻|三刻|(\d{1,2}|` + cnNumber + `)分?)?`), func(s *state, m []string) error {
+		hour, err := parseNumber(m[1])
+		if err != nil {
+			return err
+		}
+		minute := 0
+		switch m[2] {
+		case "":
+		case "半":
+			minute = 30
+		case "一刻":
+			minute = 15
+		case "三刻":
+			minute = 45
+		default:
+			if minute, err = parseNumber(m[3]); err != nil {
+				return err
+			}
+		}
+		return s.setTime(hour, minute, 0)
+	}},
+	// 单独的整点，例如 "tomorrow at 5"（at 已作为分隔符去掉）：1 到 7 点理解为下午和傍晚
+	{regexp.MustCompile(`^(\d{1,2})\b`), func(s *state, m []string) error {
+		hour := atoi(m[1])
+		if hour >= 1 && hour <= 7 {
+			hour += 12
+		}
+		return s.setTime(hour, 0, 0)
+	}},
+}
+
+// 设置年月日，拒绝 2 月 30 日这类不存在的日期
+func (s *state) setAbsoluteDate(year, month, day int) error {
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, s.now.Location())
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return fmt.Errorf("日期 %04d-%02d-%02d 不存在", year, month, day)
+	}
+	return s.setDate(date)
+}
+
+// 设置省略年份的日期，今年已经过去时理解为明年
+func (s *state) setMonthDay(month, day int) error {
+	year := s.now.Year()
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, s.now.Location())
+	if date.Before(startOfDay(s.now)) {
+		year++
+	}
+	return s.setAbsoluteDate(year, month, day)
+}
+
+func (s *state) setMeridiem(value string) error {
+	switch strings.ReplaceAll(value, ".", "") {
+	case "am":
+		return s.setPeriod("am")
+	case "pm":
+		return s.setPeriod("pm")
+	}
+	return nil
+}
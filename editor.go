@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kakkk/todo_cli/dateparse"
+)
+
+// ====================== 外部编辑器 ======================
+
+// 文档格式：front matter 中是各字段，分隔线之后是备注
+//
+//	---
+//	title: 写周报
+//	priority: P1
+//	due: 2026-10-18 17:00
+//	tags: work, report
+//	---
+//	备注（Markdown）
+const (
+	documentSeparator  = "---"
+	documentTimeLayout = "2006-01-02 15:04"
+)
+
+// 编辑器退出后返回的消息
+type editorFinishedMsg struct {
+	id   string
+	path string
+	err  error
+}
+
+// 把任务写入文档
+func marshalDocument(item TodoItem) string {
+	var b strings.Builder
+	b.WriteString(documentSeparator + "\n")
+	b.WriteString("# 保存并退出后生效；due 留空表示没有截止日期，支持 明天下午3点、fri 9am 等写法\n")
+	fmt.Fprintf(&b, "title: %s\n", item.Title)
+	fmt.Fprintf(&b, "priority: %s\n", item.Priority)
+	due := ""
+	if item.HasDeadline {
+		due = item.Deadline.Format(documentTimeLayout)
+	}
+	fmt.Fprintf(&b, "due: %s\n", due)
+	fmt.Fprintf(&b, "tags: %s\n", strings.Join(item.Tags, ", "))
+	b.WriteString(documentSeparator + "\n")
+	if item.Notes != "" {
+		b.WriteString(item.Notes + "\n")
+	}
+	return b.String()
+}
+
+// 解析文档并应用到任务上，出错时返回带行号的错误
+func parseDocument(text string, item TodoItem) (TodoItem, error) {
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		lineNo++
+		return strings.TrimRight(scanner.Text(), "\r"), true
+	}
+
+	// 跳过开头的空行
+	line, ok := next()
+	for ok && strings.TrimSpace(line) == "" {
+		line, ok = next()
+	}
+	if !ok || strings.TrimSpace(line) != documentSeparator {
+		return item, fmt.Errorf("第 %d 行：文档需要以 --- 开头", lineNo)
+	}
+
+	seen := make(map[string]bool)
+	var titleTags []string
+	closed := false
+	for {
+		line, ok = next()
+		if !ok {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == documentSeparator {
+			closed = true
+			break
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			return item, fmt.Errorf("第 %d 行：应为 字段: 值 的形式", lineNo)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if seen[key] {
+			return item, fmt.Errorf("第 %d 行：字段 %s 重复", lineNo, key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "title":
+			title, tags := parseTitleTags(value)
+			if title == "" {
+				return item, fmt.Errorf("第 %d 行：标题不能为空", lineNo)
+			}
+			item.Title = title
+			titleTags = tags
+		case "priority":
+			priority, err := ParsePriority(value)
+			if err != nil {
+				return item, fmt.Errorf("第 %d 行：%v", lineNo, err)
+			}
+			item.Priority = priority
+		case "due":
+			if value == "" || value == "-" || strings.EqualFold(value, "none") {
+				item.HasDeadline = false
+				item.Deadline = time.Time{}
+				item.Recurrence = Recurrence{}
+				continue
+			}
+			// 没改动时保留原值，避免丢掉秒或时区
+			if item.HasDeadline && value == item.Deadline.Format(documentTimeLayout) {
+				continue
+			}
+			deadline, err := dateparse.Parse(value, time.Now())
+			if err != nil {
+				return item, fmt.Errorf("第 %d 行：%v", lineNo, err)
+			}
+			item.HasDeadline = true
+			item.Deadline = deadline
+		case "tags":
+			item.Tags = parseTagList(value)
+		default:
+			return item, fmt.Errorf("第 %d 行：未知字段 %s，可用 title / priority / due / tags", lineNo, key)
+		}
+	}
+	if !closed {
+		return item, fmt.Errorf("第 %d 行：front matter 缺少结尾的 ---", lineNo)
+	}
+	if !seen["title"] {
+		return item, errors.New("缺少 title 字段")
+	}
+	// 标题中的 #标签 追加到标签上
+	for _, tag := range titleTags {
+		item.Tags = appendTag(item.Tags, tag)
+	}
+
+	var notes []string
+	for {
+		line, ok = next()
+		if !ok {
+			break
+		}
+		notes = append(notes, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return item, fmt.Errorf("读取文档失败: %v", err)
+	}
+	item.Notes = normalizeNotes(strings.Join(notes, "\n"))
+	return item, nil
+}
+
+// 用户设置的编辑器命令，依次读取 $VISUAL、$EDITOR，默认 vi
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = []string{"vi"}
+	}
+	return exec.Command(fields[0], append(fields[1:], path)...)
+}
+
+// ====================== 模型操作 ======================
+
+// 在外部编辑器中编辑选中任务，上次保存失败的内容会带回来继续修改
+func (m *Model) startExternalEdit() tea.Cmd {
+	index := m.currentIndex()
+	if index < 0 {
+		return nil
+	}
+	item := m.items[index]
+
+	text := marshalDocument(item)
+	if m.editorDraft.id == item.id && m.editorDraft.text != "" {
+		text = m.editorDraft.text
+	}
+
+	file, err := os.CreateTemp("", "todo_cli-*.md")
+	if err != nil {
+		m.statusLine = fmt.Sprintf("创建临时文件失败: %v", err)
+		return nil
+	}
+	path := file.Name()
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		m.statusLine = fmt.Sprintf("写入临时文件失败: %v", err)
+		return nil
+	}
+
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editorFinishedMsg{id: item.id, path: path, err: err}
+	})
+}
+
+// 编辑器退出后读回文档并保存
+func (m *Model) finishExternalEdit(msg editorFinishedMsg) {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		m.statusLine = fmt.Sprintf("编辑器退出异常: %v", msg.err)
+		return
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.statusLine = fmt.Sprintf("读取临时文件失败: %v", err)
+		return
+	}
+	text := string(data)
+	if strings.TrimSpace(text) == "" {
+		m.editorDraft.id, m.editorDraft.text = "", ""
+		m.statusLine = "文档为空，已取消修改"
+		return
+	}
+
+	index := m.indexByID(msg.id)
+	if index < 0 {
+		m.statusLine = "任务已不存在"
+		return
+	}
+	updated, err := parseDocument(text, m.items[index].clone())
+	if err != nil {
+		// 保留这次的内容，再按 E 时接着改
+		m.editorDraft.id, m.editorDraft.text = msg.id, text
+		m.statusLine = err.Error() + "，按 E 继续修改"
+		return
+	}
+	m.editorDraft.id, m.editorDraft.text = "", ""
+
+	if marshalDocument(updated) == marshalDocument(m.items[index]) {
+		m.statusLine = "没有修改"
+		return
+	}
+	m.items[index] = updated
+	m.updateItem(updated)
+	m.findItemByID(msg.id)
+	if m.statusLine == "" {
+		m.statusLine = fmt.Sprintf("已更新「%s」", updated.Title)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ====================== 导出 ======================
+
+// 导入导出共用的文件格式：write 写入任务列表（已按树形排好序）及其所属项目，
+// read 读回待导入的任务
+type fileFormat struct {
+	name       string
+	extensions []string
+	write      func(w io.Writer, items TodoList, projects []Project) error
+	read       func(r io.Reader) ([]importedTask, error)
+}
+
+var fileFormats = []fileFormat{
+	{"json", []string{".json"}, writeJSONExport, readJSONImport},
+	{"csv", []string{".csv"}, writeCSVExport, readCSVImport},
+	{"md", []string{".md", ".markdown"}, writeMarkdownExport, readMarkdownImport},
+	{"todotxt", []string{".txt"}, writeTodoTxtExport, readTodoTxtImport},
+	{"ics", []string{".ics"}, writeICalExport, readICalImport},
+}
+
+// CSV 的列，导入时按同样的表头读取
+var csvHeader = []string{
+	"id", "title", "done", "priority", "deadline", "tags", "project", "parent",
+	"repeat", "every", "on", "until", "notes", "created", "updated",
+}
+
+const csvTimeLayout = "2006-01-02 15:04"
+
+func fileFormatNames() string {
+	names := make([]string, 0, len(fileFormats))
+	for _, format := range fileFormats {
+		names = append(names, format.name)
+	}
+	return strings.Join(names, "/")
+}
+
+// 按名称查找格式，名称为空时根据文件扩展名判断
+func findFileFormat(name, path string) (fileFormat, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, format := range fileFormats {
+		if name == format.name {
+			return format, nil
+		}
+		if name == "" {
+			for _, candidate := range format.extensions {
+				if ext == candidate {
+					return format, nil
+				}
+			}
+		}
+	}
+	if name == "" {
+		return fileFormat{}, fmt.Errorf("无法根据文件名 %q 判断格式，请用 --format 指定 %s", path, fileFormatNames())
+	}
+	return fileFormat{}, fmt.Errorf("未知的格式 %q，可选 %s", name, fileFormatNames())
+}
+
+// JSON 导出与 JSON 存储的文件格式相同，保留 id、时间等全部字段
+func writeJSONExport(w io.Writer, items TodoList, projects []Project) error {
+	file := taskFile{Tasks: make([]taskRecord, 0, len(items))}
+	for _, project := range projects {
+		file.Projects = append(file.Projects, projectToRecord(project))
+	}
+	for _, item := range items {
+		file.Tasks = append(file.Tasks, itemToRecord(item))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return fmt.Errorf("导出 JSON 失败: %v", err)
+	}
+	return nil
+}
+
+func writeCSVExport(w io.Writer, items TodoList, projects []Project) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("导出 CSV 失败: %v", err)
+	}
+	for _, item := range items {
+		record := []string{
+			item.id,
+			item.Title,
+			strconv.FormatBool(item.Done),
+			item.Priority.String(),
+			"",
+			strings.Join(item.Tags, ","),
+			"",
+			item.ParentID,
+			"", "", "", "",
+			item.Notes,
+			formatCSVTimestamp(item.CreatedAt),
+			formatCSVTimestamp(item.UpdatedAt),
+		}
+		if item.HasDeadline {
+			record[4] = item.Deadline.Format(csvTimeLayout)
+		}
+		if item.ProjectID != inboxProjectID {
+			record[6] = projectName(projects, item.ProjectID)
+		}
+		if rule := item.Recurrence; rule.Enabled() {
+			record[8] = frequencyNames[rule.Frequency]
+			record[9] = strconv.Itoa(rule.interval())
+			var days []string
+			for _, day := range rule.Weekdays {
+				days = append(days, strings.ToLower(day.String()[:3]))
+			}
+			record[10] = strings.Join(days, ",")
+			if !rule.Until.IsZero() {
+				record[11] = rule.Until.Format(csvTimeLayout)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("导出 CSV 失败: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("导出 CSV 失败: %v", err)
+	}
+	return nil
+}
+
+func formatCSVTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Markdown 清单，子任务缩进，例如 - [ ] P0 写周报 (截止 2026-10-18 17:00) #work
+func writeMarkdownExport(w io.Writer, items TodoList, projects []Project) error {
+	depths := items.depths()
+	for _, item := range items {
+		check := " "
+		if item.Done {
+			check = "x"
+		}
+		line := fmt.Sprintf("%s- [%s] %s %s", strings.Repeat("  ", depths[item.id]), check, item.Priority, item.Title)
+		if item.HasDeadline {
+			line += fmt.Sprintf(" (截止 %s)", item.DeadlineString())
+		}
+		if len(item.Tags) > 0 {
+			line += " " + formatTags(item.Tags)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("导出 Markdown 失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// 导出到文件，先写临时文件，避免写到一半时覆盖原有文件
+func exportToFile(path string, format fileFormat, items TodoList, projects []Project) error {
+	var b strings.Builder
+	if err := format.write(&b, items, projects); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, []byte(b.String()))
+}
+
+// 展开路径开头的 ~
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// ====================== 模型操作 ======================
+
+// 输入导出路径，默认导出为 Markdown 清单
+func (m *Model) startExport() {
+	m.mode = ModeInputTitle
+	m.inputContext = InputContextExport
+	m.input.SetValue(fmt.Sprintf("~/todo-%s.md", time.Now().Format("2006-01-02")))
+	m.input.Placeholder = "导出路径，扩展名决定格式 .md/.csv/.json/.txt/.ics"
+	m.input.Focus()
+	m.input.CursorEnd()
+	m.statusLine = "导出当前过滤条件下的任务 • Enter 确认 • Esc 取消"
+}
+
+// 导出当前项目、标签和搜索条件下的任务（包括折叠起来的子任务）
+func (m *Model) confirmExport(value string) bool {
+	if value == "" {
+		m.statusLine = "导出路径不能为空"
+		return false
+	}
+	path := expandHome(value)
+	format, err := findFileFormat("", path)
+	if err != nil {
+		m.statusLine = err.Error()
+		return false
+	}
+
+	var items TodoList
+	for i := range m.items {
+		if m.matchesFilter(&m.items[i]) {
+			items = append(items, m.items[i])
+		}
+	}
+	if err := exportToFile(path, format, items, m.projects); err != nil {
+		m.statusLine = err.Error()
+		return false
+	}
+	m.statusLine = fmt.Sprintf("已导出 %d 个任务到 %s", len(items), path)
+	return true
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ====================== 撤销 / 重做 ======================
+
+// 最多保留的撤销步数
+const historyLimit = 100
+
+// 一个任务在一次操作前后的状态，nil 表示不存在（新建或已删除）
+type itemChange struct {
+	before *TodoItem
+	after  *TodoItem
+}
+
+// 一次按键产生的全部修改，撤销和重做都以它为单位
+type historyEntry struct {
+	time    time.Time
+	changes []itemChange
+}
+
+// 撤销历史，path 非空时保存到文件，重启后仍可撤销
+type History struct {
+	path    string
+	undo    []historyEntry
+	redo    []historyEntry
+	pending []itemChange // 当前按键产生、尚未提交的修改
+}
+
+// 历史文件中的记录，任务沿用 JSON 存储的格式
+type historyFile struct {
+	Undo []historyRecord `json:"undo"`
+	Redo []historyRecord `json:"redo"`
+}
+
+type historyRecord struct {
+	Time    time.Time      `json:"time"`
+	Changes []changeRecord `json:"changes"`
+}
+
+type changeRecord struct {
+	Before *taskRecord `json:"before,omitempty"`
+	After  *taskRecord `json:"after,omitempty"`
+}
+
+func newHistory() *History {
+	return &History{}
+}
+
+// 从文件加载历史，文件不存在时返回空历史
+func LoadHistory(path string) (*History, error) {
+	history := &History{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return history, fmt.Errorf("读取撤销历史失败: %v", err)
+	}
+
+	var file historyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return history, fmt.Errorf("解析撤销历史失败: %v", err)
+	}
+	if history.undo, err = entriesFromRecords(file.Undo); err != nil {
+		return history, err
+	}
+	if history.redo, err = entriesFromRecords(file.Redo); err != nil {
+		return history, err
+	}
+	return history, nil
+}
+
+// 历史文件路径，内存存储不保存历史
+func historyPath() string {
+	switch os.Getenv("TODO_CLI_STORE") {
+	case "memory":
+		return ""
+	}
+	if path := os.Getenv("TODO_CLI_PATH"); path != "" {
+		return path + ".history.json"
+	}
+	return dataFile("history.json")
+}
+
+// 记录一个任务的修改，before / after 会被复制
+func (h *History) record(before, after *TodoItem) {
+	change := itemChange{}
+	if before != nil {
+		item := before.clone()
+		change.before = &item
+	}
+	if after != nil {
+		item := after.clone()
+		change.after = &item
+	}
+	h.pending = append(h.pending, change)
+}
+
+// 把当前按键产生的修改作为一步保存下来，新的修改会清空重做栈
+func (h *History) commit() error {
+	if len(h.pending) == 0 {
+		return nil
+	}
+	h.undo = append(h.undo, historyEntry{time: time.Now(), changes: h.pending})
+	if len(h.undo) > historyLimit {
+		h.undo = h.undo[len(h.undo)-historyLimit:]
+	}
+	h.redo = nil
+	h.pending = nil
+	return h.save()
+}
+
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+	file := historyFile{Undo: entriesToRecords(h.undo), Redo: entriesToRecords(h.redo)}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("序列化撤销历史失败: %v", err)
+	}
+	return writeFileAtomic(h.path, data)
+}
+
+func entriesToRecords(entries []historyEntry) []historyRecord {
+	records := make([]historyRecord, 0, len(entries))
+	for _, entry := range entries {
+		record := historyRecord{Time: entry.time}
+		for _, change := range entry.changes {
+			var cr changeRecord
+			if change.before != nil {
+				before := itemToRecord(*change.before)
+				cr.Before = &before
+			}
+			if change.after != nil {
+				after := itemToRecord(*change.after)
+				cr.After = &after
+			}
+			record.Changes = append(record.Changes, cr)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func entriesFromRecords(records []historyRecord) ([]historyEntry, error) {
+	entries := make([]historyEntry, 0, len(records))
+	for _, record := range records {
+		entry := historyEntry{time: record.Time}
+		for _, cr := range record.Changes {
+			var change itemChange
+			if cr.Before != nil {
+				item, err := recordToItem(*cr.Before)
+				if err != nil {
+					return nil, fmt.Errorf("解析撤销历史失败: %v", err)
+				}
+				change.before = &item
+			}
+			if cr.After != nil {
+				item, err := recordToItem(*cr.After)
+				if err != nil {
+					return nil, fmt.Errorf("解析撤销历史失败: %v", err)
+				}
+				change.after = &item
+			}
+			entry.changes = append(entry.changes, change)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// 描述一步操作，用于状态栏提示，例如 删除「写周报」等 3 项
+func (e historyEntry) describe() string {
+	first := e.changes[0]
+	var action string
+	var title string
+	switch {
+	case first.before == nil:
+		action, title = "添加", first.after.Title
+	case first.after == nil:
+		action, title = "删除", first.before.Title
+	default:
+		action, title = "修改", first.after.Title
+	}
+	text := fmt.Sprintf("%s「%s」", action, title)
+	if len(e.changes) > 1 {
+		text += fmt.Sprintf("等 %d 项", len(e.changes))
+	}
+	return text
+}
+
+// 撤销或重做后每个任务的目标状态，nil 表示应当不存在
+func (e historyEntry) targets(undo bool) map[string]*TodoItem {
+	targets := make(map[string]*TodoItem)
+	if undo {
+		// 倒序回放，同一任务以最早的 before 为准
+		for i := len(e.changes) - 1; i >= 0; i-- {
+			change := e.changes[i]
+			targets[changeID(change)] = change.before
+		}
+	} else {
+		for _, change := range e.changes {
+			targets[changeID(change)] = change.after
+		}
+	}
+	return targets
+}
+
+func changeID(change itemChange) string {
+	if change.before != nil {
+		return change.before.id
+	}
+	return change.after.id
+}
+
+// ====================== 模型操作 ======================
+
+// 撤销上一步操作
+func (m *Model) undo() {
+	if len(m.history.undo) == 0 {
+		m.statusLine = "没有可以撤销的操作"
+		return
+	}
+	entry := m.history.undo[len(m.history.undo)-1]
+	if !m.replayHistory(entry, true) {
+		return
+	}
+	m.history.undo = m.history.undo[:len(m.history.undo)-1]
+	m.history.redo = append(m.history.redo, entry)
+	m.statusLine = "已撤销：" + entry.describe()
+	if err := m.history.save(); err != nil {
+		m.statusLine = err.Error()
+	}
+}
+
+// 重做上一次撤销的操作
+func (m *Model) redo() {
+	if len(m.history.redo) == 0 {
+		m.statusLine = "没有可以重做的操作"
+		return
+	}
+	entry := m.history.redo[len(m.history.redo)-1]
+	if !m.replayHistory(entry, false) {
+		return
+	}
+	m.history.redo = m.history.redo[:len(m.history.redo)-1]
+	m.history.undo = append(m.history.undo, entry)
+	m.statusLine = "已重做：" + entry.describe()
+	if err := m.history.save(); err != nil {
+		m.statusLine = err.Error()
+	}
+}
+
+// 要恢复的任务中不在界面里的，读取它们在回收站中的修订号（移入回收站时修订号会加一）
+func (m *Model) trashRevisions(targets map[string]*TodoItem) (map[string]int, error) {
+	revisions := make(map[string]int)
+	needed := false
+	for id, target := range targets {
+		if _, ok := m.persisted[id]; target != nil && !ok {
+			needed = true
+		}
+	}
+	if !needed || m.storage == nil {
+		return revisions, nil
+	}
+	trash, err := m.storage.LoadTrash()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range trash {
+		revisions[item.id] = item.Revision
+	}
+	return revisions, nil
+}
+
+// 把一步操作涉及的任务恢复到操作前（undo）或操作后的状态，写入存储后同步到列表
+func (m *Model) replayHistory(entry historyEntry, undo bool) bool {
+	targets := entry.targets(undo)
+	trashed, err := m.trashRevisions(targets)
+	if err != nil {
+		m.statusLine = err.Error()
+		return false
+	}
+
+	var upserts []TodoItem
+	var deleteIDs []string
+	seen := make(map[string]bool)
+	for _, change := range entry.changes {
+		id := changeID(change)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if target := targets[id]; target == nil {
+			deleteIDs = append(deleteIDs, id)
+		} else {
+			// 历史中记录的修订号可能已经过时，以界面或回收站中的为准，都不在时沿用记录的
+			item := target.clone()
+			if current, ok := m.persisted[id]; ok {
+				item.Revision = current.Revision
+			} else if revision, ok := trashed[id]; ok {
+				item.Revision = revision
+			}
+			upserts = append(upserts, item)
+		}
+	}
+
+	if m.storage != nil {
+		if err := m.storage.Apply(upserts, deleteIDs); err != nil {
+			m.statusLine = err.Error()
+			return false
+		}
+	}
+
+	// 与存储保持一致：先删除（连同子任务），再写入
+	removed := make(map[string]bool)
+	for _, id := range deleteIDs {
+		removed[id] = true
+		for _, childID := range m.items.descendantIDs(id) {
+			removed[childID] = true
+		}
+	}
+	kept := m.items[:0]
+	for _, item := range m.items {
+		if !removed[item.id] {
+			kept = append(kept, item)
+		}
+	}
+	m.items = kept
+	for id := range removed {
+		delete(m.persisted, id)
+	}
+	for _, item := range upserts {
+		item.Revision++
+		if index := m.indexByID(item.id); index >= 0 {
+			m.items[index] = item
+		} else {
+			m.items = append(m.items, item)
+		}
+		m.persisted[item.id] = item.clone()
+	}
+	m.sortItems()
+
+	// 选中恢复出来的任务，方便确认
+	if target := targets[changeID(entry.changes[0])]; target != nil {
+		delete(m.collapsed, target.ParentID)
+		m.findItemByID(target.id)
+	} else {
+		m.findItemByID(m.selectedID)
+	}
+	return true
+}
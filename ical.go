@@ -0,0 +1,525 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kakkk/todo_cli/dateparse"
+)
+
+// ====================== iCalendar 格式 ======================
+
+// 每个任务导出为一个 VTODO（UID 即任务 id），可选为未完成且有截止日期的任务
+// 额外导出一个 VEVENT 提醒，让截止日期出现在日历中。导入时同时读取 VTODO 和
+// 日历应用导出的 VEVENT，VEVENT 的开始时间作为截止日期
+const (
+	icalUTCLayout      = "20060102T150405Z"
+	icalLocalLayout    = "20060102T150405"
+	icalDateLayout     = "20060102"
+	icalLineLimit      = 75          // 每行最多 75 个字节，超出时折行
+	icalReminderSuffix = "-reminder" // 提醒事件的 UID 为任务 id 加上该后缀
+	icalReminderLead   = 30 * time.Minute
+	icalProjectProp    = "X-TODO-CLI-PROJECT"
+)
+
+// RFC 5545 中 1~4 为高、5 为中、6~9 为低，0 表示未指定
+var icalPriorities = map[Priority]int{
+	PriorityHigh:   1,
+	PriorityMedium: 5,
+	PriorityLow:    9,
+}
+
+func parseICalPriority(value string) Priority {
+	n, err := strconv.Atoi(value)
+	switch {
+	case err != nil || n == 0 || n == 5:
+		return PriorityMedium
+	case n < 5:
+		return PriorityHigh
+	}
+	return PriorityLow
+}
+
+var icalFrequencies = map[Frequency]string{
+	FrequencyDaily:   "DAILY",
+	FrequencyWeekly:  "WEEKLY",
+	FrequencyMonthly: "MONTHLY",
+	FrequencyYearly:  "YEARLY",
+}
+
+// ---------------------- 导出 ----------------------
+
+// 按 RFC 5545 写入内容行：CRLF 换行，超长时在字符边界折行
+type icalWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (iw *icalWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+	text := name + ":" + value
+	var b strings.Builder
+	limit := icalLineLimit
+	for len(text) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		b.WriteString(text[:cut] + "\r\n ")
+		text = text[cut:]
+		// 续行开头的空格也计入长度
+		limit = icalLineLimit - 1
+	}
+	b.WriteString(text + "\r\n")
+	_, iw.err = io.WriteString(iw.w, b.String())
+}
+
+func escapeICalText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(value)
+}
+
+func formatICalTime(t time.Time) string {
+	return t.UTC().Format(icalUTCLayout)
+}
+
+func formatRRule(rule Recurrence) string {
+	parts := []string{"FREQ=" + icalFrequencies[rule.Frequency]}
+	if rule.interval() > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rule.interval()))
+	}
+	if len(rule.Weekdays) > 0 {
+		var days []string
+		for _, day := range rule.Weekdays {
+			days = append(days, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if !rule.Until.IsZero() {
+		parts = append(parts, "UNTIL="+formatICalTime(rule.Until))
+	}
+	return strings.Join(parts, ";")
+}
+
+// 导出格式列表中的 ics 只包含 VTODO
+func writeICalExport(w io.Writer, items TodoList, projects []Project) error {
+	return writeICalendar(w, items, projects, false)
+}
+
+// 导出 VTODO，events 为 true 时同时导出提醒事件
+func writeICalendar(w io.Writer, items TodoList, projects []Project, events bool) error {
+	iw := &icalWriter{w: w}
+	now := time.Now()
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//kakkk//todo_cli//ZH")
+	iw.line("CALSCALE", "GREGORIAN")
+
+	for _, item := range items {
+		iw.line("BEGIN", "VTODO")
+		iw.line("UID", item.id)
+		iw.line("DTSTAMP", formatICalTime(cmp.Or(item.UpdatedAt, now)))
+		if !item.CreatedAt.IsZero() {
+			iw.line("CREATED", formatICalTime(item.CreatedAt))
+		}
+		if !item.UpdatedAt.IsZero() {
+			iw.line("LAST-MODIFIED", formatICalTime(item.UpdatedAt))
+		}
+		iw.line("SUMMARY", escapeICalText(item.Title))
+		if item.Notes != "" {
+			iw.line("DESCRIPTION", escapeICalText(item.Notes))
+		}
+		if item.HasDeadline {
+			iw.line("DUE", formatICalTime(item.Deadline))
+			if item.Recurrence.Enabled() {
+				iw.line("RRULE", formatRRule(item.Recurrence))
+			}
+		}
+		iw.line("PRIORITY", strconv.Itoa(icalPriorities[item.Priority]))
+		if item.Done {
+			iw.line("STATUS", "COMPLETED")
+			iw.line("COMPLETED", formatICalTime(cmp.Or(item.UpdatedAt, now)))
+		} else {
+			iw.line("STATUS", "NEEDS-ACTION")
+		}
+		if len(item.Tags) > 0 {
+			tags := make([]string, len(item.Tags))
+			for i, tag := range item.Tags {
+				tags[i] = escapeICalText(tag)
+			}
+			iw.line("CATEGORIES", strings.Join(tags, ","))
+		}
+		if item.ParentID != "" {
+			iw.line("RELATED-TO", item.ParentID)
+		}
+		if item.ProjectID != inboxProjectID {
+			iw.line(icalProjectProp, escapeICalText(projectName(projects, item.ProjectID)))
+		}
+		iw.line("END", "VTODO")
+	}
+
+	for _, item := range items {
+		if !events || item.Done || !item.HasDeadline {
+			continue
+		}
+		iw.line("BEGIN", "VEVENT")
+		iw.line("UID", item.id+icalReminderSuffix)
+		iw.line("DTSTAMP", formatICalTime(cmp.Or(item.UpdatedAt, now)))
+		iw.line("DTSTART", formatICalTime(item.Deadline.Add(-icalReminderLead)))
+		iw.line("DTEND", formatICalTime(item.Deadline))
+		iw.line("SUMMARY", escapeICalText("截止："+item.Title))
+		if item.Recurrence.Enabled() {
+			iw.line("RRULE", formatRRule(item.Recurrence))
+		}
+		iw.line("RELATED-TO", item.id)
+		iw.line("BEGIN", "VALARM")
+		iw.line("ACTION", "DISPLAY")
+		iw.line("DESCRIPTION", escapeICalText(item.Title))
+		iw.line("TRIGGER", "PT0S")
+		iw.line("END", "VALARM")
+		iw.line("END", "VEVENT")
+	}
+
+	iw.line("END", "VCALENDAR")
+	if iw.err != nil {
+		return fmt.Errorf("导出 iCalendar 失败: %v", iw.err)
+	}
+	return nil
+}
+
+// ---------------------- 导入 ----------------------
+
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+type icalComponent struct {
+	kind  string // VTODO 或 VEVENT
+	line  int    // BEGIN 所在行，用于报错
+	props []icalProperty
+}
+
+func (c *icalComponent) get(name string) (icalProperty, bool) {
+	for _, prop := range c.props {
+		if prop.name == name {
+			return prop, true
+		}
+	}
+	return icalProperty{}, false
+}
+
+func (c *icalComponent) text(name string) string {
+	prop, _ := c.get(name)
+	return unescapeICalText(prop.value)
+}
+
+// 解析 NAME;PARAM=VALUE:VALUE 形式的内容行，参数值可以带引号
+func parseICalProperty(line string) (icalProperty, error) {
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return icalProperty{}, fmt.Errorf("无效的内容行 %q", line)
+	}
+	head := strings.Split(line[:colon], ";")
+	prop := icalProperty{
+		name:   strings.ToUpper(head[0]),
+		params: make(map[string]string, len(head)-1),
+		value:  line[colon+1:],
+	}
+	for _, param := range head[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, nil
+}
+
+func unescapeICalText(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// 按未转义的逗号分隔
+func splitICalList(value string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// 支持 UTC 时间、带 TZID 的本地时间和全天日期，全天日期使用与输入截止日期相同的默认时刻
+func parseICalTime(prop icalProperty) (time.Time, error) {
+	value := prop.value
+	if prop.params["VALUE"] == "DATE" || len(value) == len(icalDateLayout) {
+		date, err := time.ParseInLocation(icalDateLayout, value, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s 的日期 %q 无效", prop.name, value)
+		}
+		return date.Add(dateparse.DefaultHour*time.Hour + dateparse.DefaultMinute*time.Minute), nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalUTCLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s 的时间 %q 无效", prop.name, value)
+		}
+		return t.Local(), nil
+	}
+	// 无法识别的时区（例如 Windows 的时区名称）按本地时间处理
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(icalLocalLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s 的时间 %q 无效", prop.name, value)
+	}
+	return t.Local(), nil
+}
+
+// 只支持能对应到重复规则的 RRULE，其他写法（COUNT、BYMONTHDAY 等）返回错误
+func parseRRule(value string) (Recurrence, error) {
+	var rule Recurrence
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			for frequency, name := range icalFrequencies {
+				if strings.EqualFold(val, name) {
+					rule.Frequency = frequency
+				}
+			}
+			if !rule.Enabled() {
+				return Recurrence{}, fmt.Errorf("不支持的重复频率 %s", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return Recurrence{}, fmt.Errorf("无效的重复间隔 %s", val)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			var days []string
+			for _, day := range strings.Split(val, ",") {
+				if len(day) != 2 {
+					return Recurrence{}, fmt.Errorf("不支持的 BYDAY %s", val)
+				}
+				days = append(days, day)
+			}
+			weekdays, err := parseICalWeekdays(days)
+			if err != nil {
+				return Recurrence{}, err
+			}
+			rule.Weekdays = weekdays
+		case "UNTIL":
+			until, err := parseICalTime(icalProperty{name: "UNTIL", params: map[string]string{}, value: val})
+			if err != nil {
+				return Recurrence{}, err
+			}
+			rule.Until = until
+		case "WKST":
+			// 每周从哪天开始不影响重复规则
+		default:
+			return Recurrence{}, fmt.Errorf("不支持的重复规则 %s", part)
+		}
+	}
+	if !rule.Enabled() {
+		return Recurrence{}, errors.New("重复规则缺少 FREQ")
+	}
+	return rule, nil
+}
+
+func parseICalWeekdays(days []string) ([]time.Weekday, error) {
+	var weekdays []time.Weekday
+	for _, day := range days {
+		found := false
+		for _, weekday := range weekdayOrder {
+			if strings.EqualFold(day, weekday.String()[:2]) {
+				weekdays = append(weekdays, weekday)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("无效的星期 %s", day)
+		}
+	}
+	return weekdays, nil
+}
+
+// 读取 VTODO 和 VEVENT，嵌套的组件（VALARM 等）以及本工具导出的提醒事件忽略
+func readICalImport(r io.Reader) ([]importedTask, error) {
+	components, err := readICalComponents(r)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := make(map[string]bool)
+	for _, c := range components {
+		if c.kind == "VTODO" {
+			todos[c.text("UID")] = true
+		}
+	}
+
+	var tasks []importedTask
+	for _, c := range components {
+		uid := c.text("UID")
+		if c.kind == "VEVENT" && strings.HasSuffix(uid, icalReminderSuffix) && todos[strings.TrimSuffix(uid, icalReminderSuffix)] {
+			continue
+		}
+		task, err := icalComponentToTask(&c)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行的 %s：%v", c.line, c.kind, err)
+		}
+		if task.item.Title != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// 展开折行，按 BEGIN/END 收集顶层的 VTODO 和 VEVENT
+func readICalComponents(r io.Reader) ([]icalComponent, error) {
+	type contentLine struct {
+		text string
+		no   int
+	}
+	var lines []contentLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for no := 1; scanner.Scan(); no++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if no == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, contentLine{text, no})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 iCalendar 失败: %v", err)
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0].text, "BEGIN:VCALENDAR") {
+		return nil, errors.New("不是 iCalendar 文件，应以 BEGIN:VCALENDAR 开头")
+	}
+
+	var components []icalComponent
+	var current *icalComponent
+	depth := 0 // current 内部嵌套组件的层数
+	for _, line := range lines {
+		prop, err := parseICalProperty(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行：%v", line.no, err)
+		}
+		value := strings.ToUpper(prop.value)
+		switch {
+		case prop.name == "BEGIN" && current == nil && (value == "VTODO" || value == "VEVENT"):
+			current = &icalComponent{kind: value, line: line.no}
+		case prop.name == "BEGIN" && current != nil:
+			depth++
+		case prop.name == "END" && current != nil && depth > 0:
+			depth--
+		case prop.name == "END" && current != nil:
+			components = append(components, *current)
+			current = nil
+		case current != nil && depth == 0:
+			current.props = append(current.props, prop)
+		}
+	}
+	if current != nil {
+		return nil, fmt.Errorf("第 %d 行的 %s 缺少 END", current.line, current.kind)
+	}
+	return components, nil
+}
+
+func icalComponentToTask(c *icalComponent) (importedTask, error) {
+	item := TodoItem{
+		Title:    strings.Join(strings.Fields(c.text("SUMMARY")), " "),
+		Notes:    normalizeNotes(c.text("DESCRIPTION")),
+		Priority: PriorityMedium,
+		id:       c.text("UID"),
+	}
+	var err error
+
+	due := "DUE"
+	if c.kind == "VEVENT" {
+		due = "DTSTART"
+	}
+	if prop, ok := c.get(due); ok {
+		if item.Deadline, err = parseICalTime(prop); err != nil {
+			return importedTask{}, err
+		}
+		item.HasDeadline = true
+	}
+	if prop, ok := c.get("PRIORITY"); ok {
+		item.Priority = parseICalPriority(prop.value)
+	}
+	if _, ok := c.get("COMPLETED"); ok || strings.EqualFold(c.text("STATUS"), "COMPLETED") {
+		item.Done = true
+	}
+	for _, prop := range c.props {
+		switch {
+		case prop.name == "CATEGORIES":
+			for _, tag := range splitICalList(prop.value) {
+				item.Tags = appendTag(item.Tags, strings.Join(strings.Fields(unescapeICalText(tag)), "-"))
+			}
+		case prop.name == "RELATED-TO" && cmp.Or(strings.ToUpper(prop.params["RELTYPE"]), "PARENT") == "PARENT":
+			item.ParentID = prop.value
+		}
+	}
+	if prop, ok := c.get("CREATED"); ok {
+		item.CreatedAt, _ = parseICalTime(prop)
+	}
+	if prop, ok := c.get("LAST-MODIFIED"); ok {
+		item.UpdatedAt, _ = parseICalTime(prop)
+	}
+	// 日历应用中复杂的重复规则无法对应时只导入第一次
+	if prop, ok := c.get("RRULE"); ok && item.HasDeadline {
+		if rule, err := parseRRule(prop.value); err == nil {
+			item.Recurrence = rule
+		}
+	}
+	return importedTask{item: item, project: c.text(icalProjectProp)}, nil
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ====================== 导入 ======================
+
+// 待导入的任务
+type importedTask struct {
+	item      TodoItem
+	project   string // 项目名称，为空时导入到默认项目
+	duplicate bool   // 与已有任务重复（id 相同，或标题和截止日期都相同），不会导入
+}
+
+// JSON 与导出及 JSON 存储的格式相同，回收站中的任务不导入
+func readJSONImport(r io.Reader) ([]importedTask, error) {
+	var file taskFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析 JSON 失败: %v", err)
+	}
+	names := make(map[string]string, len(file.Projects))
+	for _, project := range file.Projects {
+		names[project.ID] = project.Name
+	}
+
+	var tasks []importedTask
+	for i, record := range file.Tasks {
+		item, err := recordToItem(record)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个任务：%v", i+1, err)
+		}
+		if item.Deleted() {
+			continue
+		}
+		project := names[item.ProjectID]
+		item.ProjectID = inboxProjectID
+		tasks = append(tasks, importedTask{item: item, project: project})
+	}
+	return tasks, nil
+}
+
+// CSV 按表头识别列，只有 title 是必需的，其余列与导出相同，可以缺少
+func readCSVImport(r io.Reader) ([]importedTask, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 CSV 失败: %v", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("CSV 缺少 title 列")
+	}
+
+	var tasks []importedTask
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 CSV 失败: %v", err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		task, err := csvRecordToTask(field)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行：%v", row, err)
+		}
+		if task.item.Title != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+func csvRecordToTask(field func(name string) string) (importedTask, error) {
+	title, tags := parseTitleTags(field("title"))
+	item := TodoItem{
+		Title:    title,
+		Notes:    normalizeNotes(field("notes")),
+		Priority: PriorityMedium,
+		ParentID: field("parent"),
+		id:       field("id"),
+	}
+	var err error
+	if value := field("done"); value != "" {
+		if item.Done, err = strconv.ParseBool(value); err != nil && value != "x" {
+			return importedTask{}, fmt.Errorf("done 列应为 true/false，而不是 %q", value)
+		}
+		item.Done = item.Done || value == "x"
+	}
+	if value := field("priority"); value != "" {
+		if item.Priority, err = ParsePriority(value); err != nil {
+			return importedTask{}, err
+		}
+	}
+	if value := field("deadline"); value != "" {
+		if item.Deadline, err = parseImportTime(value); err != nil {
+			return importedTask{}, err
+		}
+		item.HasDeadline = true
+	}
+	item.Tags = parseTagList(field("tags"))
+	for _, tag := range tags {
+		item.Tags = appendTag(item.Tags, tag)
+	}
+
+	if value := field("repeat"); value != "" {
+		rule := &item.Recurrence
+		if rule.Frequency, err = ParseFrequency(value); err != nil {
+			return importedTask{}, err
+		}
+		if every := field("every"); every != "" {
+			if rule.Interval, err = strconv.Atoi(every); err != nil || rule.Interval < 1 {
+				return importedTask{}, fmt.Errorf("every 列应为正整数，而不是 %q", every)
+			}
+		}
+		if rule.Weekdays, err = parseWeekdays(field("on")); err != nil {
+			return importedTask{}, err
+		}
+		if until := field("until"); until != "" {
+			if rule.Until, err = parseImportTime(until); err != nil {
+				return importedTask{}, err
+			}
+		}
+		if rule.Enabled() && !item.HasDeadline {
+			return importedTask{}, errors.New("重复任务需要截止日期")
+		}
+	}
+
+	for name, target := range map[string]*time.Time{"created": &item.CreatedAt, "updated": &item.UpdatedAt} {
+		if value := field(name); value != "" {
+			if *target, err = time.Parse(time.RFC3339, value); err != nil {
+				return importedTask{}, fmt.Errorf("%s 列应为 RFC 3339 时间，而不是 %q", name, value)
+			}
+		}
+	}
+	return importedTask{item: item, project: field("project")}, nil
+}
+
+// 导入的时间优先按导出格式解析，其他写法交给自然语言解析
+func parseImportTime(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation(csvTimeLayout, value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return parseDue(value)
+}
+
+var (
+	// - [ ] 或 * [x] 开头的清单项，前面的空白表示层级
+	checklistPattern = regexp.MustCompile(`^(\s*)[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	// 行首的优先级
+	checklistPriorityPattern = regexp.MustCompile(`^(?i)(P[0-2])\s+`)
+	// (截止 …) 或 (due …)
+	checklistDuePattern = regexp.MustCompile(`\s*[(（](?:截止|due)[:：]?\s*([^)）]+)[)）]`)
+)
+
+// Markdown 只读取清单项，其他内容（会议记录的正文等）忽略；缩进的清单项作为子任务
+func readMarkdownImport(r io.Reader) ([]importedTask, error) {
+	type level struct {
+		indent int
+		id     string
+	}
+	var stack []level
+	var tasks []importedTask
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		match := checklistPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		indent := len(strings.ReplaceAll(match[1], "\t", "    "))
+		text := match[3]
+
+		item := TodoItem{Done: match[2] != " ", Priority: PriorityMedium, id: generateID()}
+		if m := checklistPriorityPattern.FindStringSubmatch(text); m != nil {
+			item.Priority, _ = ParsePriority(m[1])
+			text = text[len(m[0]):]
+		}
+		if m := checklistDuePattern.FindStringSubmatch(text); m != nil {
+			deadline, err := parseImportTime(strings.TrimSpace(m[1]))
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行：%v", lineNo, err)
+			}
+			item.HasDeadline = true
+			item.Deadline = deadline
+			text = strings.Replace(text, m[0], "", 1)
+		}
+		item.Title, item.Tags = parseTitleTags(text)
+		if item.Title == "" {
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			item.ParentID = stack[len(stack)-1].id
+		}
+		stack = append(stack, level{indent: indent, id: item.id})
+		tasks = append(tasks, importedTask{item: item})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 Markdown 失败: %v", err)
+	}
+	return tasks, nil
+}
+
+// 读取并解析导入文件
+func readImportFile(path, formatName string) ([]importedTask, error) {
+	format, err := findFileFormat(formatName, path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 %s 失败: %v", path, err)
+	}
+	defer file.Close()
+	return format.read(file)
+}
+
+// 标出与已有任务重复的项，并把父任务 id 指向实际存在的任务：
+// 文件中的父任务重复时指向已有的那个，都找不到时作为顶层任务导入。
+// id 与回收站中的任务相同时换一个新 id，避免覆盖已删除的任务
+func planImport(tasks []importedTask, existing, trashed TodoList) {
+	byID := make(map[string]bool, len(existing))
+	byKey := make(map[string]string, len(existing))
+	for _, item := range existing {
+		byID[item.id] = true
+		byKey[importKey(item)] = item.id
+	}
+	inTrash := make(map[string]bool, len(trashed))
+	for _, item := range trashed {
+		inTrash[item.id] = true
+	}
+
+	ids := make(map[string]string, len(tasks)) // 文件中的 id → 导入后的 id
+	for i := range tasks {
+		item := &tasks[i].item
+		fileID := item.id
+		switch {
+		case fileID != "" && byID[fileID]:
+			tasks[i].duplicate = true
+		case byKey[importKey(*item)] != "":
+			tasks[i].duplicate = true
+			item.id = byKey[importKey(*item)]
+		case fileID == "" || ids[fileID] != "" || inTrash[fileID]:
+			item.id = generateID()
+		}
+		if fileID != "" {
+			ids[fileID] = item.id
+		}
+		// 文件内标题和截止日期相同的任务也只导入一次
+		if !tasks[i].duplicate {
+			byKey[importKey(*item)] = item.id
+		}
+	}
+
+	for i := range tasks {
+		item := &tasks[i].item
+		if item.ParentID == "" {
+			continue
+		}
+		if id, ok := ids[item.ParentID]; ok {
+			item.ParentID = id
+		} else if !byID[item.ParentID] {
+			item.ParentID = ""
+		}
+	}
+}
+
+// 标题和截止时刻相同视为同一任务；截止时间统一换成 UTC，文件和存储中的时区写法不同也能对上
+func importKey(item TodoItem) string {
+	key := item.Title
+	if item.HasDeadline {
+		key += "\x00" + item.Deadline.UTC().Format(time.RFC3339)
+	}
+	return key
+}
+
+// 需要写入的新任务，projectID 把项目名称解析为 id，名称为空时使用 defaultProject
+func importUpserts(tasks []importedTask, defaultProject string, projectID func(name string) (string, error)) ([]TodoItem, error) {
+	var upserts []TodoItem
+	for _, task := range tasks {
+		if task.duplicate {
+			continue
+		}
+		item := task.item.clone()
+		item.ProjectID = defaultProject
+		item.Revision = 0 // 导入的是新任务，不沿用文件中的修订号
+		if task.project != "" {
+			id, err := projectID(task.project)
+			if err != nil {
+				return nil, err
+			}
+			item.ProjectID = id
+		}
+		if item.CreatedAt.IsZero() {
+			item.touch()
+		}
+		upserts = append(upserts, item)
+	}
+	return upserts, nil
+}
+
+func countDuplicates(tasks []importedTask) int {
+	count := 0
+	for _, task := range tasks {
+		if task.duplicate {
+			count++
+		}
+	}
+	return count
+}
+
+// ====================== 导入预览 ======================
+
+func (m *Model) startImport() {
+	m.mode = ModeInputTitle
+	m.inputContext = InputContextImport
+	m.input.SetValue("")
+	m.input.Placeholder = "导入文件路径 .md/.csv/.json/.txt/.ics"
+	m.input.Focus()
+	m.statusLine = "输入路径后回车预览 • Esc 取消"
+}
+
+// 解析文件并进入预览
+func (m *Model) confirmImportPath(value string) bool {
+	if value == "" {
+		m.statusLine = "导入路径不能为空"
+		return false
+	}
+	path := expandHome(value)
+	tasks, err := readImportFile(path, "")
+	if err != nil {
+		m.statusLine = err.Error()
+		return false
+	}
+	if len(tasks) == 0 {
+		m.statusLine = "文件中没有可以导入的任务"
+		return false
+	}
+	var trashed TodoList
+	if m.storage != nil {
+		if trashed, err = m.storage.LoadTrash(); err != nil {
+			m.statusLine = err.Error()
+			return false
+		}
+	}
+	planImport(tasks, m.items, trashed)
+
+	m.input.Blur()
+	m.mode = ModeImport
+	m.importPreview.path = path
+	m.importPreview.tasks = tasks
+	m.importPreview.cursor = 0
+	m.statusLine = ""
+	return true
+}
+
+func (m *Model) handleImportMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.importPreview.tasks = nil
+		return m.exitToNormalMode(), nil
+	case "up", "k":
+		if m.importPreview.cursor > 0 {
+			m.importPreview.cursor--
+		}
+	case "down", "j":
+		if m.importPreview.cursor < len(m.importPreview.tasks)-1 {
+			m.importPreview.cursor++
+		}
+	case "enter", "y":
+		m.commitImport()
+		m.importPreview.tasks = nil
+		return m.exitToNormalModeKeepStatus(), nil
+	}
+	return m, nil
+}
+
+// 在一个事务中写入全部新任务，整体作为一步撤销
+func (m *Model) commitImport() {
+	tasks := m.importPreview.tasks
+	defaultProject := m.targetProjectID()
+	upserts, err := importUpserts(tasks, defaultProject, func(name string) (string, error) {
+		if isInboxName(name) {
+			return inboxProjectID, nil
+		}
+		if i := findProjectByName(m.projects, name); i >= 0 {
+			return m.projects[i].id, nil
+		}
+		project, ok := m.createProject(name)
+		if !ok {
+			return "", errors.New(m.statusLine)
+		}
+		return project.id, nil
+	})
+	if err != nil {
+		m.statusLine = err.Error()
+		return
+	}
+	if len(upserts) == 0 {
+		m.statusLine = "没有新任务需要导入"
+		return
+	}
+	if !m.applyChanges(upserts, nil) {
+		return
+	}
+	m.findItemByID(upserts[0].id)
+	m.statusLine = fmt.Sprintf("已导入 %d 个任务", len(upserts))
+	if duplicates := countDuplicates(tasks); duplicates > 0 {
+		m.statusLine += fmt.Sprintf("，跳过 %d 个重复任务", duplicates)
+	}
+}
+
+func (m *Model) renderImportPreview() string {
+	tasks := m.importPreview.tasks
+	duplicates := countDuplicates(tasks)
+	title := m.styles.Header.
+		Background(lipgloss.Color("35")).
+		Foreground(lipgloss.Color("255")).
+		Render(" 导入预览 ")
+	header := " " + title + m.styles.Deadline.Render(fmt.Sprintf(" %s  新 %d 项 • 重复 %d 项", m.importPreview.path, len(tasks)-duplicates, duplicates))
+
+	var builder strings.Builder
+	builder.WriteString(header + "\n\n")
+
+	depths := make(map[string]int, len(tasks))
+	start, end := 0, len(tasks)
+	if rows := m.trashCapacity(); end > rows {
+		start = m.importPreview.cursor - rows/2
+		if start < 0 {
+			start = 0
+		}
+		if start+rows > end {
+			start = end - rows
+		}
+		end = start + rows
+	}
+	for _, task := range tasks {
+		if depth, ok := depths[task.item.ParentID]; ok {
+			depths[task.item.id] = depth + 1
+		} else {
+			depths[task.item.id] = 0
+		}
+	}
+
+	for i := start; i < end; i++ {
+		task := tasks[i]
+		mark := m.styles.Checkbox.Render("新  ")
+		if task.duplicate {
+			mark = m.styles.Deadline.Render("重复")
+		}
+		check := "○"
+		if task.item.Done {
+			check = "✓"
+		}
+		line := fmt.Sprintf("%s %s %s  %s%s", mark, check, task.item.Priority, strings.Repeat("  ", depths[task.item.id]), truncateText(task.item.Title, 40))
+		if task.item.HasDeadline {
+			line += m.styles.Deadline.Render("  " + task.item.DeadlineString())
+		}
+		if len(task.item.Tags) > 0 {
+			line += "  " + m.styles.Tag.Render(formatTags(task.item.Tags))
+		}
+		if task.project != "" {
+			line += m.styles.Deadline.Render("  @" + task.project)
+		}
+		if i == m.importPreview.cursor {
+			builder.WriteString("  " + m.styles.Cursor.Render("▶ ") + line + "\n")
+		} else {
+			builder.WriteString("    " + line + "\n")
+		}
+	}
+	if start > 0 || end < len(tasks) {
+		builder.WriteString("  " + m.renderScrollIndicator(start, end, len(tasks)) + "\n")
+	}
+	return builder.String()
+}
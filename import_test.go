@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// 同一个文件导入两次，第二次应全部识别为重复任务
+func TestImportTwice(t *testing.T) {
+	// 与 UTC 不同的时区下，文件中的 UTC 时间与存储读回的本地时间表示不同
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*60*60)
+	defer func() { time.Local = local }()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"tasks.ics": "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+			"BEGIN:VTODO\r\nSUMMARY:写周报\r\nDUE:20261020T090000Z\r\nEND:VTODO\r\n" +
+			"BEGIN:VTODO\r\nSUMMARY:交电费\r\nDUE;VALUE=DATE:20261021\r\nEND:VTODO\r\n" +
+			"BEGIN:VTODO\r\nSUMMARY:买牛奶\r\nEND:VTODO\r\n" +
+			"END:VCALENDAR\r\n",
+		"tasks.csv": "title,deadline\n" +
+			"写周报,2026-10-20T09:00:00Z\n" +
+			"交电费,2026-10-21T17:00:00+08:00\n" +
+			"买牛奶,\n",
+	}
+
+	sqliteStore, err := NewSQLiteStore(filepath.Join(dir, "todo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqliteStore.Close()
+	jsonStore, err := NewJSONStore(filepath.Join(dir, "todo.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, store := range map[string]TaskStore{"sqlite": sqliteStore, "json": jsonStore} {
+		for file, content := range files {
+			path := filepath.Join(dir, file)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			for range 2 {
+				if err := cliImport(store, []string{path}); err != nil {
+					t.Fatalf("%s %s: %v", name, file, err)
+				}
+			}
+		}
+		items, err := store.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 两个文件中的任务相同，也只导入一次
+		if len(items) != 3 {
+			t.Errorf("%s: 导入两次后有 %d 个任务，应为 3 个", name, len(items))
+		}
+	}
+}
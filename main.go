@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -16,19 +17,23 @@ type Model struct {
 	cursor       int
 	mode         Mode
 	input        textinput.Model
+	notes        textarea.Model // 备注编辑器
 	inputContext InputContext
 	statusLine   string
 	styles       *Styles
-	storage      *Storage
+	storage      TaskStore
 
 	// 用于添加新项目
 	draftItem TodoItem
+	// 正在编辑的任务ID
+	editingID string
 
 	// 日期选择器状态
 	datePicker struct {
-		index int
-		date  time.Time
-		field DateField
+		index  int
+		date   time.Time
+		field  DateField
+		typing bool // 正在用文字输入日期
 	}
 
 	// 优先级选择器状态
@@ -36,27 +41,94 @@ type Model struct {
 		priority Priority
 	}
 
-	terminalWidth int
-	selectedID    string // 跟踪当前选中的任务ID
+	// 重复规则选择器状态
+	recurrencePicker struct {
+		rule     Recurrence
+		deadline time.Time
+		field    int
+	}
+
+	terminalWidth  int
+	terminalHeight int
+	scrollOffset   int      // 表格第一行对应可见列表中的位置
+	selectedID     string   // 跟踪当前选中的任务ID
+	tagFilter      []string // 只显示包含这些标签的任务
+	searchQuery    string   // 只显示标题模糊匹配的任务
+	sortMode       SortMode // 任务列表的排序方式
+
+	projects       []Project
+	currentProject string // 当前项目ID，allProjectsID 表示全部
+
+	collapsed map[string]bool // 已折叠子任务的父任务ID
+
+	showDetail bool // 是否在表格下方显示选中任务的详情
+
+	// 导入预览状态
+	importPreview struct {
+		path   string
+		tasks  []importedTask
+		cursor int
+	}
+
+	// 外部编辑器中解析失败的内容，再次编辑同一任务时带回
+	editorDraft struct {
+		id   string
+		text string
+	}
+
+	marked       map[string]bool // 已标记的任务ID，批量操作作用于这些任务
+	visualAnchor string          // 范围选择的起点任务ID，为空表示未在选择
+
+	history   *History
+	persisted map[string]TodoItem // 存储中各任务的当前内容，用于记录修改前的状态
+
+	storeVersion int64 // 最近一次加载时的数据版本，变化时重新加载
+
+	// 确认提示状态
+	confirm struct {
+		prompt     string
+		onYes      func()
+		onNo       func()
+		returnMode Mode // 确认结束后回到的模式
+	}
+
+	// 写入冲突提示状态，同一次按键中的多个冲突依次处理
+	conflict struct {
+		queue      []writeConflict
+		returnMode Mode // 处理完冲突后回到的模式
+	}
+
+	// 回收站视图状态
+	trash struct {
+		items  TodoList
+		cursor int
+	}
 }
 
-func NewModel() *Model {
+func NewModel(storage TaskStore) *Model {
 	ti := textinput.New()
 	ti.Placeholder = "输入内容后回车确认，Esc 取消"
 	ti.Prompt = "» "
 	ti.CharLimit = 200
 	ti.Width = 40 // 设置默认宽度
 
-	// 初始化存储
-	storage, err := NewStorage()
+	// 加载任务和项目
 	var status string
-	var items TodoList
-
-	if err != nil {
-		status = fmt.Sprintf("存储初始化失败: %v", err)
-		items = TodoList{}
-	} else {
-		items, status = storage.Load()
+	items := TodoList{}
+	var projects []Project
+	var version int64
+	if storage != nil {
+		// 先读版本再加载，加载期间的外部修改会在下一次检查时发现
+		version, _ = storage.Version()
+		loaded, err := storage.Load()
+		if err != nil {
+			status = err.Error()
+		} else {
+			items = loaded
+		}
+		if projects, err = storage.LoadProjects(); err != nil {
+			status = err.Error()
+		}
 	}
 
 	model := &Model{
@@ -64,11 +136,24 @@ func NewModel() *Model {
 		cursor:       0,
 		mode:         ModeNormal,
 		input:        ti,
+		notes:        newNotesEditor(),
 		inputContext: InputContextNone,
 		statusLine:   status,
 		styles:       NewStyles(),
 		draftItem:    TodoItem{Priority: PriorityMedium},
 		storage:      storage,
+
+		projects:       projects,
+		currentProject: allProjectsID,
+		collapsed:      make(map[string]bool),
+		marked:         make(map[string]bool),
+
+		history:      newHistory(),
+		persisted:    make(map[string]TodoItem, len(items)),
+		storeVersion: version,
+	}
+	for _, item := range items {
+		model.persisted[item.id] = item.clone()
 	}
 
 	// 初始化选中的ID
@@ -80,13 +165,39 @@ func NewModel() *Model {
 }
 
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return watchStore()
 }
 
 // ====================== 主函数 ======================
 
 func main() {
-	program := tea.NewProgram(NewModel())
+	// 带子命令时以非交互方式运行
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
+	// 初始化存储
+	storage, err := OpenStore()
+	model := NewModel(storage)
+	if err != nil {
+		model.statusLine = fmt.Sprintf("存储初始化失败: %v", err)
+	}
+
+	// 沿用上次选择的排序方式
+	if config, err := LoadConfig(); err == nil && config.Sort != SortByPriority {
+		model.sortMode = config.Sort
+		model.sortItems()
+		model.findItemByID("")
+	}
+
+	// 加载撤销历史，重启后仍然可以撤销之前的操作
+	if history, err := LoadHistory(historyPath()); err != nil {
+		model.statusLine = err.Error()
+	} else {
+		model.history = history
+	}
+
+	program := tea.NewProgram(model)
 	if _, err := program.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "运行出错: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ====================== 备注 ======================
+
+const (
+	notesEditorHeight = 8
+	detailNoteLines   = 10 // 详情面板中最多显示的备注行数
+	detailWidth       = 89 // 与任务表格同宽
+)
+
+func newNotesEditor() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "支持 Markdown，可以写链接、清单和上下文"
+	ta.ShowLineNumbers = false
+	ta.CharLimit = 0
+	ta.SetWidth(detailWidth - 2)
+	ta.SetHeight(notesEditorHeight)
+	return ta
+}
+
+// 编辑选中任务的备注
+func (m *Model) startEditingNotes() tea.Cmd {
+	index := m.currentIndex()
+	if index < 0 {
+		return nil
+	}
+	m.mode = ModeEditNotes
+	m.editingID = m.items[index].id
+	m.notes.SetValue(m.items[index].Notes)
+	m.statusLine = fmt.Sprintf("编辑「%s」的备注 • ctrl+s 保存 • Esc 放弃修改", m.items[index].Title)
+	return m.notes.Focus()
+}
+
+func (m *Model) handleNotesMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.notes.Blur()
+		return m.exitToNormalMode(), nil
+	case "ctrl+s":
+		m.saveNotes(m.notes.Value())
+		m.notes.Blur()
+		return m.exitToNormalModeKeepStatus(), nil
+	}
+
+	var cmd tea.Cmd
+	m.notes, cmd = m.notes.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) saveNotes(value string) {
+	index := m.indexByID(m.editingID)
+	if index < 0 {
+		return
+	}
+	value = normalizeNotes(value)
+	if value == m.items[index].Notes {
+		m.statusLine = ""
+		return
+	}
+	m.items[index].Notes = value
+	m.updateItem(m.items[index])
+	m.findItemByID(m.editingID)
+	if m.statusLine == "" {
+		m.statusLine = "已保存备注"
+	}
+}
+
+// 去掉首尾空行和行尾空白
+func normalizeNotes(value string) string {
+	lines := strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// ====================== 详情面板 ======================
+
+func (m *Model) toggleDetail() {
+	m.showDetail = !m.showDetail
+}
+
+// 任务的各项属性，详情面板和命令行 show 共用
+func detailFields(item TodoItem, projects []Project) [][2]string {
+	fields := [][2]string{
+		{"ID", item.id},
+		{"项目", projectName(projects, item.ProjectID)},
+		{"优先级", item.Priority.String()},
+		{"截止", item.DeadlineString()},
+	}
+	if item.Recurrence.Enabled() {
+		fields = append(fields, [2]string{"重复", item.Recurrence.String()})
+	}
+	if len(item.Tags) > 0 {
+		fields = append(fields, [2]string{"标签", formatTags(item.Tags)})
+	}
+	fields = append(fields,
+		[2]string{"创建", formatTimestamp(item.CreatedAt)},
+		[2]string{"更新", formatTimestamp(item.UpdatedAt)},
+	)
+	return fields
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// 把若干段文字用 sep 连接，超出宽度时整段换到下一行
+func joinWrapped(parts []string, sep string, width int) []string {
+	var lines []string
+	var current string
+	for _, part := range parts {
+		switch {
+		case current == "":
+			current = part
+		case lipgloss.Width(current+sep+part) > width:
+			lines = append(lines, current)
+			current = part
+		default:
+			current += sep + part
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// 选中任务的详情，显示在表格下方；未打开或没有选中任务时为空
+func (m *Model) renderDetail() string {
+	if !m.showDetail || m.inTrash() {
+		return ""
+	}
+	index := m.currentIndex()
+	if index < 0 {
+		return ""
+	}
+	item := m.items[index]
+
+	var lines []string
+	lines = append(lines, m.styles.Header.Render(item.Title))
+	var meta []string
+	for _, field := range detailFields(item, m.projects) {
+		meta = append(meta, m.styles.Deadline.Render(field[0]+" ")+field[1])
+	}
+	lines = append(lines, joinWrapped(meta, "  ", detailWidth-4)...)
+	lines = append(lines, "")
+
+	if item.Notes == "" {
+		lines = append(lines, m.styles.Help.Render("暂无备注，按 n 添加"))
+	} else {
+		notes := strings.Split(lipgloss.NewStyle().Width(detailWidth-4).Render(item.Notes), "\n")
+		if len(notes) > detailNoteLines {
+			hidden := len(notes) - detailNoteLines + 1
+			notes = append(notes[:detailNoteLines-1], m.styles.Help.Render(fmt.Sprintf("… 还有 %d 行，按 n 查看全部", hidden)))
+		}
+		lines = append(lines, notes...)
+	}
+
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("236")).
+		Padding(0, 1).
+		Width(detailWidth)
+	return box.Render(strings.Join(lines, "\n")) + "\n"
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"strings"
+)
+
+// ====================== 项目 ======================
+
+const (
+	// 未归属任何项目的任务属于收件箱
+	inboxProjectID   = ""
+	inboxProjectName = "收件箱"
+	// 项目切换栏中的"全部"，不对项目过滤
+	allProjectsID   = "*"
+	allProjectsName = "全部"
+)
+
+// 新建项目时依次使用的颜色
+var projectColors = []string{"62", "168", "35", "214", "39", "135", "203", "108"}
+
+type Project struct {
+	Name     string
+	Color    string
+	Archived bool
+	id       string
+}
+
+func newProject(name string, existing []Project) Project {
+	return Project{
+		Name:  name,
+		Color: projectColors[len(existing)%len(projectColors)],
+		id:    generateID(),
+	}
+}
+
+// 按名称查找项目（不区分大小写），找不到时返回 -1
+func findProjectByName(projects []Project, name string) int {
+	name = strings.TrimSpace(name)
+	for i, project := range projects {
+		if strings.EqualFold(project.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isInboxName(name string) bool {
+	name = strings.TrimSpace(name)
+	return name == inboxProjectName || strings.EqualFold(name, "inbox")
+}
+
+// 根据项目 id 返回显示名称
+func projectName(projects []Project, id string) string {
+	switch id {
+	case inboxProjectID:
+		return inboxProjectName
+	case allProjectsID:
+		return allProjectsName
+	}
+	for _, project := range projects {
+		if project.id == id {
+			return project.Name
+		}
+	}
+	return "?"
+}
+
+// ====================== 项目切换 ======================
+
+// 项目切换栏中的标签页：全部、收件箱以及未归档的项目
+func (m *Model) projectTabs() []Project {
+	tabs := []Project{
+		{Name: allProjectsName, id: allProjectsID},
+		{Name: inboxProjectName, id: inboxProjectID},
+	}
+	for _, project := range m.projects {
+		if !project.Archived {
+			tabs = append(tabs, project)
+		}
+	}
+	return tabs
+}
+
+func (m *Model) switchProject(delta int) {
+	tabs := m.projectTabs()
+	current := 0
+	for i, tab := range tabs {
+		if tab.id == m.currentProject {
+			current = i
+			break
+		}
+	}
+	next := (current + delta + len(tabs)) % len(tabs)
+	m.currentProject = tabs[next].id
+	m.findItemByID(m.selectedID)
+}
+
+// 新任务归属的项目，在"全部"视图下添加时放进收件箱
+func (m *Model) targetProjectID() string {
+	if m.currentProject == allProjectsID {
+		return inboxProjectID
+	}
+	return m.currentProject
+}
+
+func (m *Model) startAddingProject() {
+	m.mode = ModeInputTitle
+	m.inputContext = InputContextAddProject
+	m.input.SetValue("")
+	m.input.Placeholder = "新项目名称"
+	m.input.Focus()
+	m.statusLine = "输入项目名称后回车，Esc 取消"
+}
+
+func (m *Model) startMovingItem() {
+	index := m.currentIndex()
+	if index < 0 {
+		return
+	}
+	m.mode = ModeInputTitle
+	m.inputContext = InputContextMoveProject
+	m.editingID = m.items[index].id
+	m.input.SetValue("")
+	m.input.Placeholder = "当前：" + projectName(m.projects, m.items[index].ProjectID) + "，输入目标项目，不存在时自动创建"
+	m.input.Focus()
+	m.statusLine = "输入项目名称后回车，Esc 取消"
+}
+
+// 创建项目并切换过去
+func (m *Model) confirmAddProject(name string) {
+	if name == "" {
+		m.statusLine = "项目名称不能为空"
+		return
+	}
+	if isInboxName(name) || name == allProjectsName || findProjectByName(m.projects, name) >= 0 {
+		m.statusLine = "项目 " + name + " 已存在"
+		return
+	}
+
+	project, ok := m.createProject(name)
+	if !ok {
+		return
+	}
+	m.currentProject = project.id
+	m.findItemByID(m.selectedID)
+	m.statusLine = "已创建项目 " + project.Name
+}
+
+// 把正在编辑的任务移动到指定项目，项目不存在时自动创建
+func (m *Model) confirmMoveItem(name string) {
+	index := m.indexByID(m.editingID)
+	if index < 0 {
+		return
+	}
+
+	projectID := inboxProjectID
+	if name != "" && !isInboxName(name) {
+		if i := findProjectByName(m.projects, name); i >= 0 {
+			projectID = m.projects[i].id
+		} else {
+			project, ok := m.createProject(name)
+			if !ok {
+				return
+			}
+			projectID = project.id
+		}
+	}
+
+	// 子任务跟随父任务一起移动
+	for _, id := range append([]string{m.editingID}, m.items.descendantIDs(m.editingID)...) {
+		if i := m.indexByID(id); i >= 0 {
+			m.items[i].ProjectID = projectID
+			m.updateItem(m.items[i])
+		}
+	}
+	m.findItemByID(m.editingID)
+	if m.statusLine == "" {
+		m.statusLine = "已移动到 " + projectName(m.projects, projectID)
+	}
+}
+
+func (m *Model) createProject(name string) (Project, bool) {
+	project := newProject(name, m.projects)
+	if m.storage != nil {
+		if err := m.storage.SaveProject(project); err != nil {
+			m.statusLine = err.Error()
+			return Project{}, false
+		}
+	}
+	m.projects = append(m.projects, project)
+	return project, true
+}
+
+// 归档当前项目，归档后不再出现在切换栏中
+func (m *Model) archiveCurrentProject() {
+	for i := range m.projects {
+		if m.projects[i].id != m.currentProject {
+			continue
+		}
+		project := m.projects[i]
+		project.Archived = true
+		if m.storage != nil {
+			if err := m.storage.SaveProject(project); err != nil {
+				m.statusLine = err.Error()
+				return
+			}
+		}
+		m.projects[i] = project
+		m.currentProject = allProjectsID
+		m.findItemByID(m.selectedID)
+		m.statusLine = "已归档项目 " + project.Name
+		return
+	}
+	m.statusLine = "全部和收件箱不能归档"
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ====================== 重复规则 ======================
+
+type Frequency int
+
+const (
+	FrequencyNone Frequency = iota
+	FrequencyDaily
+	FrequencyWeekly
+	FrequencyMonthly
+	FrequencyYearly
+)
+
+var frequencyNames = []string{"none", "daily", "weekly", "monthly", "yearly"}
+
+func (f Frequency) String() string {
+	switch f {
+	case FrequencyDaily:
+		return "天"
+	case FrequencyWeekly:
+		return "周"
+	case FrequencyMonthly:
+		return "月"
+	case FrequencyYearly:
+		return "年"
+	default:
+		return "不重复"
+	}
+}
+
+func ParseFrequency(s string) (Frequency, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for i, name := range frequencyNames {
+		if s == name {
+			return Frequency(i), nil
+		}
+	}
+	return FrequencyNone, fmt.Errorf("无效的重复频率 %q，可选 %s", s, strings.Join(frequencyNames, "/"))
+}
+
+// 星期按周一到周日排列
+var weekdayOrder = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday,
+	time.Friday, time.Saturday, time.Sunday,
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday: "一", time.Tuesday: "二", time.Wednesday: "三", time.Thursday: "四",
+	time.Friday: "五", time.Saturday: "六", time.Sunday: "日",
+}
+
+var weekdayAbbrs = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+type Recurrence struct {
+	Frequency Frequency
+	Interval  int            // 间隔，至少为 1
+	Weekdays  []time.Weekday // 每周重复时在哪几天，为空时沿用截止日期所在的星期
+	Until     time.Time      // 最后一次重复的日期，零值表示不结束
+}
+
+func (r Recurrence) Enabled() bool {
+	return r.Frequency != FrequencyNone
+}
+
+func (r Recurrence) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+	return r.Interval
+}
+
+func (r Recurrence) hasWeekday(day time.Weekday) bool {
+	for _, d := range r.Weekdays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// 计算 from 之后的下一次时间，超过结束日期时返回 false
+func (r Recurrence) Next(from time.Time) (time.Time, bool) {
+	var next time.Time
+	switch r.Frequency {
+	case FrequencyDaily:
+		next = from.AddDate(0, 0, r.interval())
+	case FrequencyWeekly:
+		next = r.nextWeekly(from)
+	case FrequencyMonthly:
+		next = addMonths(from, r.interval())
+	case FrequencyYearly:
+		next = addMonths(from, 12*r.interval())
+	default:
+		return time.Time{}, false
+	}
+
+	if !r.Until.IsZero() {
+		until := r.Until
+		end := time.Date(until.Year(), until.Month(), until.Day(), 23, 59, 59, 0, from.Location())
+		if next.After(end) {
+			return time.Time{}, false
+		}
+	}
+	return next, true
+}
+
+func (r Recurrence) nextWeekly(from time.Time) time.Time {
+	if len(r.Weekdays) == 0 {
+		return from.AddDate(0, 0, 7*r.interval())
+	}
+	// 同一周内后面的日子可以直接使用，否则跳过 interval 周
+	startWeek := weekIndex(from)
+	for days := 1; days <= 7*(r.interval()+1); days++ {
+		candidate := from.AddDate(0, 0, days)
+		if (weekIndex(candidate)-startWeek)%r.interval() == 0 && r.hasWeekday(candidate.Weekday()) {
+			return candidate
+		}
+	}
+	return from.AddDate(0, 0, 7*r.interval())
+}
+
+// 以周一为一周开始的周序号
+func weekIndex(t time.Time) int {
+	days := int(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400)
+	// 1970-01-01 是周四，偏移到周一
+	return (days + 3) / 7
+}
+
+// 按月份加减，日期超出目标月份天数时取当月最后一天
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1,
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+func (r Recurrence) String() string {
+	if !r.Enabled() {
+		return r.Frequency.String()
+	}
+	text := "每" + r.Frequency.String()
+	if r.interval() > 1 {
+		text = fmt.Sprintf("每 %d %s", r.interval(), r.Frequency)
+	}
+	if r.Frequency == FrequencyWeekly && len(r.Weekdays) > 0 {
+		text += " " + formatWeekdays(r.Weekdays)
+	}
+	if !r.Until.IsZero() {
+		text += " 至 " + r.Until.Format("2006-01-02")
+	}
+	return text
+}
+
+func formatWeekdays(days []time.Weekday) string {
+	var names []string
+	for _, day := range weekdayOrder {
+		for _, d := range days {
+			if d == day {
+				names = append(names, weekdayNames[day])
+				break
+			}
+		}
+	}
+	return "周" + strings.Join(names, "、")
+}
+
+// 解析 mon,wed,fri 形式的星期列表
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		day, ok := weekdayAbbrs[field]
+		if !ok {
+			return nil, fmt.Errorf("无效的星期 %q，可选 mon/tue/wed/thu/fri/sat/sun", field)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// 重复任务完成后生成下一次任务，没有下一次时返回 false
+func nextOccurrence(item TodoItem) (TodoItem, bool) {
+	if !item.Recurrence.Enabled() || !item.HasDeadline {
+		return TodoItem{}, false
+	}
+	deadline, ok := item.Recurrence.Next(item.Deadline)
+	// 跳过已经过去的重复，避免补出一串过期任务
+	for ok && deadline.Before(time.Now()) {
+		deadline, ok = item.Recurrence.Next(deadline)
+	}
+	if !ok {
+		return TodoItem{}, false
+	}
+
+	next := item.clone()
+	next.id = generateID()
+	next.Done = false
+	next.Deadline = deadline
+	next.CreatedAt = time.Time{}
+	next.touch()
+	return next, true
+}
+
+// ====================== 重复规则选择器 ======================
+
+// 选择器中的字段，星期字段占 7 个位置（周一到周日）
+const (
+	recurrenceFieldFrequency = iota
+	recurrenceFieldInterval
+	recurrenceFieldWeekday
+	recurrenceFieldUntil = recurrenceFieldWeekday + 7
+	recurrenceFieldCount = recurrenceFieldUntil + 1
+)
+
+func (m *Model) startRecurrencePicker(rule Recurrence, deadline time.Time) {
+	m.mode = ModePickRecurrence
+	m.recurrencePicker.rule = rule
+	m.recurrencePicker.rule.Weekdays = append([]time.Weekday(nil), rule.Weekdays...)
+	m.recurrencePicker.deadline = deadline
+	m.recurrencePicker.field = recurrenceFieldFrequency
+	m.statusLine = "←/→ 切换字段 • ↑/↓ 调整 • 空格 选择星期 • Enter 确认 • Esc 不重复"
+}
+
+func (m *Model) handleRecurrencePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		return m.cancelPicker(), nil
+	case "enter":
+		return m.confirmRecurrenceSelection(), nil
+	case "left", "h":
+		m.rotateRecurrenceField(-1)
+	case "right", "l":
+		m.rotateRecurrenceField(1)
+	case "up", "k":
+		m.adjustRecurrence(1)
+	case "down", "j":
+		m.adjustRecurrence(-1)
+	case " ":
+		if m.recurrencePicker.field >= recurrenceFieldWeekday && m.recurrencePicker.field < recurrenceFieldUntil {
+			m.adjustRecurrence(1)
+		}
+	}
+	return m, nil
+}
+
+// 字段是否可以选中：不重复时只有频率，非每周时跳过星期
+func (m *Model) recurrenceFieldAvailable(field int) bool {
+	rule := m.recurrencePicker.rule
+	switch {
+	case field == recurrenceFieldFrequency:
+		return true
+	case !rule.Enabled():
+		return false
+	case field >= recurrenceFieldWeekday && field < recurrenceFieldUntil:
+		return rule.Frequency == FrequencyWeekly
+	}
+	return true
+}
+
+func (m *Model) rotateRecurrenceField(delta int) {
+	field := m.recurrencePicker.field
+	for i := 0; i < recurrenceFieldCount; i++ {
+		field = (field + delta + recurrenceFieldCount) % recurrenceFieldCount
+		if m.recurrenceFieldAvailable(field) {
+			m.recurrencePicker.field = field
+			return
+		}
+	}
+}
+
+func (m *Model) adjustRecurrence(delta int) {
+	rule := &m.recurrencePicker.rule
+	field := m.recurrencePicker.field
+	switch {
+	case field == recurrenceFieldFrequency:
+		rule.Frequency = Frequency((int(rule.Frequency) + delta + len(frequencyNames)) % len(frequencyNames))
+		if rule.Interval < 1 {
+			rule.Interval = 1
+		}
+	case field == recurrenceFieldInterval:
+		rule.Interval += delta
+		if rule.Interval < 1 {
+			rule.Interval = 1
+		}
+		if rule.Interval > 99 {
+			rule.Interval = 99
+		}
+	case field == recurrenceFieldUntil:
+		deadline := m.recurrencePicker.deadline
+		if rule.Until.IsZero() {
+			if delta > 0 {
+				rule.Until = addMonths(deadline, 1)
+			}
+			return
+		}
+		rule.Until = rule.Until.AddDate(0, 0, 7*delta)
+		// 早于截止日期时视为不结束
+		if rule.Until.Before(deadline) {
+			rule.Until = time.Time{}
+		}
+	default:
+		day := weekdayOrder[field-recurrenceFieldWeekday]
+		if rule.hasWeekday(day) {
+			var days []time.Weekday
+			for _, d := range rule.Weekdays {
+				if d != day {
+					days = append(days, d)
+				}
+			}
+			rule.Weekdays = days
+		} else {
+			rule.Weekdays = append(rule.Weekdays, day)
+		}
+	}
+}
+
+func (m *Model) confirmRecurrenceSelection() tea.Model {
+	m.statusLine = ""
+	rule := m.recurrencePicker.rule
+	if !rule.Enabled() {
+		rule = Recurrence{}
+	}
+
+	if m.inputContext == InputContextAddPriority {
+		m.draftItem.Recurrence = rule
+		m.saveDraft()
+	} else if index := m.indexByID(m.editingID); index >= 0 {
+		m.items[index].Recurrence = rule
+		m.updateItem(m.items[index])
+		m.findItemByID(m.editingID)
+	}
+
+	return m.exitToNormalModeKeepStatus()
+}
+
+// 完成重复任务后生成下一次任务
+func (m *Model) scheduleNextOccurrence(item TodoItem) {
+	next, ok := nextOccurrence(item)
+	if !ok {
+		return
+	}
+	m.createItem(next)
+	if m.statusLine != "" {
+		return
+	}
+
+	// 已完成的任务不再携带规则，避免反复勾选时重复生成
+	if index := m.indexByID(item.id); index >= 0 {
+		m.items[index].Recurrence = Recurrence{}
+		m.updateItem(m.items[index])
+	}
+	if m.statusLine == "" {
+		m.statusLine = "已生成下一次任务，截止 " + next.DeadlineString()
+	}
+}
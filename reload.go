@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ====================== 外部修改 ======================
+
+// 检查数据版本的间隔
+const reloadInterval = 2 * time.Second
+
+type reloadTickMsg struct{}
+
+func watchStore() tea.Cmd {
+	return tea.Tick(reloadInterval, func(time.Time) tea.Msg {
+		return reloadTickMsg{}
+	})
+}
+
+// 数据版本变化时重新加载（另一个实例、命令行或 HTTP 接口写入了数据）。
+// 只在普通模式下加载，输入或预览时等回到普通模式后再处理
+func (m *Model) checkExternalChanges() {
+	if m.storage == nil || m.mode != ModeNormal {
+		return
+	}
+	version, err := m.storage.Version()
+	if err != nil || version == m.storeVersion {
+		return
+	}
+	// 先记下版本再加载，加载期间的修改会在下一次检查时发现
+	m.storeVersion = version
+	m.reload()
+}
+
+// 重新加载任务和项目，保持选中的任务不变；选中的任务被删除时停在原来的行
+func (m *Model) reload() {
+	items, err := m.storage.Load()
+	if err != nil {
+		m.statusLine = err.Error()
+		return
+	}
+	projects, err := m.storage.LoadProjects()
+	if err != nil {
+		m.statusLine = err.Error()
+		return
+	}
+
+	// 自己写入后也会触发加载，只有内容不同时才提示
+	changed := 0
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		seen[item.id] = true
+		if before, ok := m.persisted[item.id]; !ok || !sameTask(before, item) {
+			changed++
+		}
+	}
+	for id := range m.persisted {
+		if !seen[id] {
+			changed++
+		}
+	}
+
+	cursor := m.cursor
+	m.items = items
+	m.projects = projects
+	// 当前项目在别处被归档时回到全部
+	if !slices.ContainsFunc(m.projectTabs(), func(tab Project) bool { return tab.id == m.currentProject }) {
+		m.currentProject = allProjectsID
+	}
+	m.sortItems()
+	m.persisted = make(map[string]TodoItem, len(items))
+	for _, item := range items {
+		m.persisted[item.id] = item.clone()
+	}
+	for id := range m.marked {
+		if !seen[id] {
+			delete(m.marked, id)
+		}
+	}
+	if !seen[m.visualAnchor] {
+		m.visualAnchor = ""
+	}
+
+	if seen[m.selectedID] {
+		m.findItemByID(m.selectedID)
+	} else if visible := m.visibleIndexes(); len(visible) > 0 {
+		m.findItemByID(m.items[visible[min(cursor, len(visible)-1)]].id)
+	}
+	if changed > 0 {
+		m.statusLine = fmt.Sprintf("已载入其他地方的 %d 处修改", changed)
+	}
+}
+
+// 比较两个任务的内容。不比较修改时间，
+// SQLite 保存标签时会重写修改时间，自己的写入也会与界面中的不同
+func sameTask(a, b TodoItem) bool {
+	return a.id == b.id && len(changedFields(a, b)) == 0
+}
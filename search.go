@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ====================== 搜索 ======================
+
+// 模糊匹配：查询按空格分词，每个词的字符都按顺序出现在文本中即可，忽略大小写
+// 例如 "fxlg" 可以匹配 "fix login page"
+func fuzzyMatch(query, text string) bool {
+	text = strings.ToLower(text)
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !isSubsequence(term, text) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSubsequence(term, text string) bool {
+	pattern := []rune(term)
+	i := 0
+	for _, r := range text {
+		if i == len(pattern) {
+			break
+		}
+		if r == pattern[i] {
+			i++
+		}
+	}
+	return i == len(pattern)
+}
+
+// 进入搜索模式，继续编辑已有的搜索词
+func (m *Model) startSearch() tea.Cmd {
+	m.mode = ModeSearch
+	m.input.SetValue(m.searchQuery)
+	m.input.Placeholder = "搜索标题，支持模糊匹配"
+	m.input.CursorEnd()
+	m.statusLine = "输入即过滤 • ↑/↓ 移动 • Enter 保留过滤 • Esc 清除"
+	return m.input.Focus()
+}
+
+func (m *Model) handleSearchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.clearSearch()
+		return m.exitToNormalMode(), nil
+	case "enter":
+		return m.exitToNormalMode(), nil
+	case "up", "ctrl+p":
+		m.moveCursor(-1)
+		return m, nil
+	case "down", "ctrl+n":
+		m.moveCursor(1)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.setSearchQuery(m.input.Value())
+	return m, cmd
+}
+
+// 更新搜索词，尽量保持选中原来的任务
+func (m *Model) setSearchQuery(query string) {
+	m.searchQuery = strings.TrimSpace(query)
+	m.findItemByID(m.selectedID)
+}
+
+func (m *Model) clearSearch() {
+	if m.searchQuery != "" {
+		m.setSearchQuery("")
+	}
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ====================== HTTP 接口 ======================
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+const (
+	defaultServeAddr = "127.0.0.1:7878"
+	maxRequestBody   = 1 << 20
+)
+
+type apiServer struct {
+	mu      sync.Mutex // 存储后端不保证并发安全，请求逐个处理
+	storage TaskStore
+	token   string
+}
+
+// 返回的任务与 JSON 存储的字段相同，另外附带项目名称和是否过期
+type apiTask struct {
+	taskRecord
+	ProjectName string `json:"project_name"`
+	Overdue     bool   `json:"overdue"`
+}
+
+// 新建和修改任务的请求体，修改时只更新出现的字段
+type taskInput struct {
+	Title    *string   `json:"title"`
+	Notes    *string   `json:"notes"`
+	Done     *bool     `json:"done"`
+	Priority *string   `json:"priority"`
+	Deadline *string   `json:"deadline"` // RFC 3339、2006-01-02 15:04 或 明天下午3点 等写法，空字符串清除
+	Tags     *[]string `json:"tags"`
+	Project  *string   `json:"project"`  // 项目名称，不存在时自动创建，空字符串为收件箱
+	Parent   *string   `json:"parent"`   // 父任务 id，空字符串表示顶层任务
+	Revision *int      `json:"revision"` // 修改时读取到的修订号，与存储中的不同时返回 409 且不写入
+}
+
+// 带 HTTP 状态码的错误
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...any) error {
+	return &apiError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func notFound(id string) error {
+	return &apiError{http.StatusNotFound, errTaskNotFound(id)}
+}
+
+func newAPIHandler(storage TaskStore, token string) http.Handler {
+	s := &apiServer{storage: storage, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+	mux.HandleFunc("GET /tasks", s.handle(s.listTasks))
+	mux.HandleFunc("POST /tasks", s.handle(s.createTask))
+	mux.HandleFunc("GET /tasks/{id}", s.handle(s.getTask))
+	mux.HandleFunc("PATCH /tasks/{id}", s.handle(s.patchTask))
+	mux.HandleFunc("DELETE /tasks/{id}", s.handle(s.deleteTask))
+	mux.HandleFunc("GET /projects", s.handle(s.listProjects))
+	return mux
+}
+
+// 校验令牌、串行执行处理函数，并把结果或错误写成 JSON。
+// 没有令牌时只接受 Host 为本机的请求，防止网页通过 DNS 重绑定访问接口
+func (s *apiServer) handle(fn func(r *http.Request) (int, any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token == "" && !isLoopbackHost(requestHost(r)) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": fmt.Sprintf("未设置访问令牌，拒绝 Host 为 %s 的请求", r.Host)})
+			return
+		}
+		if s.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "缺少或错误的访问令牌"})
+				return
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+
+		s.mu.Lock()
+		status, body, err := fn(r)
+		s.mu.Unlock()
+
+		if err != nil {
+			status = http.StatusInternalServerError
+			var apiErr *apiError
+			var conflict *ConflictError
+			switch {
+			case errors.As(err, &apiErr):
+				status = apiErr.status
+			case errors.As(err, &conflict):
+				status = http.StatusConflict
+			}
+			writeJSON(w, status, map[string]string{"error": err.Error()})
+			return
+		}
+		if body == nil {
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(w, status, body)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(body)
+}
+
+func (s *apiServer) load() (TodoList, []Project, error) {
+	items, err := s.storage.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	projects, err := s.storage.LoadProjects()
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, projects, nil
+}
+
+func toAPITask(item TodoItem, projects []Project) apiTask {
+	return apiTask{
+		taskRecord:  itemToRecord(item),
+		ProjectName: projectName(projects, item.ProjectID),
+		Overdue:     item.IsOverdue(),
+	}
+}
+
+// GET /tasks?done=false&priority=P0,P1&overdue=true&project=work&tag=report
+func (s *apiServer) listTasks(r *http.Request) (int, any, error) {
+	query := r.URL.Query()
+	var filters []func(item *TodoItem) bool
+
+	for _, name := range []string{"done", "overdue"} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		want, err := strconv.ParseBool(value)
+		if err != nil {
+			return 0, nil, badRequest("%s 应为 true 或 false", name)
+		}
+		if name == "done" {
+			filters = append(filters, func(item *TodoItem) bool { return item.Done == want })
+		} else {
+			filters = append(filters, func(item *TodoItem) bool { return item.IsOverdue() == want })
+		}
+	}
+	if value := query.Get("priority"); value != "" {
+		priorities := make(map[Priority]bool)
+		for _, name := range strings.Split(value, ",") {
+			priority, err := ParsePriority(name)
+			if err != nil {
+				return 0, nil, badRequest("%v", err)
+			}
+			priorities[priority] = true
+		}
+		filters = append(filters, func(item *TodoItem) bool { return priorities[item.Priority] })
+	}
+	if value := query.Get("project"); value != "" {
+		projectID, err := resolveProject(s.storage, value, false)
+		if err != nil {
+			return 0, nil, badRequest("%v", err)
+		}
+		filters = append(filters, func(item *TodoItem) bool { return item.ProjectID == projectID })
+	}
+	if value := query.Get("tag"); value != "" {
+		tags := parseTagList(value)
+		filters = append(filters, func(item *TodoItem) bool { return hasAllTags(item.Tags, tags) })
+	}
+
+	items, projects, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	tasks := make([]apiTask, 0, len(items))
+	for i := range items {
+		matched := true
+		for _, filter := range filters {
+			matched = matched && filter(&items[i])
+		}
+		if matched {
+			tasks = append(tasks, toAPITask(items[i], projects))
+		}
+	}
+	return http.StatusOK, tasks, nil
+}
+
+func (s *apiServer) getTask(r *http.Request) (int, any, error) {
+	items, projects, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	index := indexOfID(items, r.PathValue("id"))
+	if index < 0 {
+		return 0, nil, notFound(r.PathValue("id"))
+	}
+	return http.StatusOK, toAPITask(items[index], projects), nil
+}
+
+func (s *apiServer) createTask(r *http.Request) (int, any, error) {
+	var input taskInput
+	if err := decodeInput(r, &input); err != nil {
+		return 0, nil, err
+	}
+	if input.Title == nil {
+		return 0, nil, badRequest("缺少 title")
+	}
+	items, projects, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	item := TodoItem{Priority: PriorityMedium, id: generateID()}
+	// 子任务默认与父任务在同一项目中
+	if input.Parent != nil && input.Project == nil {
+		if index := indexOfID(items, *input.Parent); index >= 0 {
+			item.ProjectID = items[index].ProjectID
+		}
+	}
+	_, project, err := applyInput(&item, input, items, projects)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.saveNewProject(project); err != nil {
+		return 0, nil, err
+	}
+	item.touch()
+	if err := s.storage.Create(item); err != nil {
+		return 0, nil, err
+	}
+	return s.respondTask(http.StatusCreated, item.id)
+}
+
+// 修改任务；把重复任务标为完成时与界面中一样生成下一次任务
+func (s *apiServer) patchTask(r *http.Request) (int, any, error) {
+	var input taskInput
+	if err := decodeInput(r, &input); err != nil {
+		return 0, nil, err
+	}
+	items, projects, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	id := r.PathValue("id")
+	index := indexOfID(items, id)
+	if index < 0 {
+		return 0, nil, notFound(id)
+	}
+
+	item := items[index].clone()
+	if input.Revision != nil {
+		// 先检查修订号，冲突时不会留下新建的项目
+		item.Revision = *input.Revision
+		if err := checkRevision(item, items[index]); err != nil {
+			return 0, nil, err
+		}
+	}
+	next, project, err := applyInput(&item, input, items, projects)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.saveNewProject(project); err != nil {
+		return 0, nil, err
+	}
+	item.touch()
+	if err := s.storage.Update(item); err != nil {
+		return 0, nil, err
+	}
+	if next != nil {
+		if err := s.storage.Create(*next); err != nil {
+			return 0, nil, err
+		}
+	}
+	return s.respondTask(http.StatusOK, id)
+}
+
+func (s *apiServer) deleteTask(r *http.Request) (int, any, error) {
+	items, err := s.storage.Load()
+	if err != nil {
+		return 0, nil, err
+	}
+	id := r.PathValue("id")
+	if indexOfID(items, id) < 0 {
+		return 0, nil, notFound(id)
+	}
+	if err := s.storage.Delete(id); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *apiServer) listProjects(r *http.Request) (int, any, error) {
+	projects, err := s.storage.LoadProjects()
+	if err != nil {
+		return 0, nil, err
+	}
+	records := make([]projectRecord, 0, len(projects))
+	for _, project := range projects {
+		records = append(records, projectToRecord(project))
+	}
+	return http.StatusOK, records, nil
+}
+
+// 重新读取写入后的任务作为响应
+func (s *apiServer) respondTask(status int, id string) (int, any, error) {
+	items, projects, err := s.load()
+	if err != nil {
+		return 0, nil, err
+	}
+	index := indexOfID(items, id)
+	if index < 0 {
+		return 0, nil, notFound(id)
+	}
+	return status, toAPITask(items[index], projects), nil
+}
+
+// 只接受 application/json 的请求体，网页中的表单无法不经预检跨站发送这种请求
+func decodeInput(r *http.Request, input *taskInput) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &apiError{http.StatusUnsupportedMediaType, errors.New("请求体须为 JSON，Content-Type 应为 application/json")}
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(input); err != nil {
+		return badRequest("请求体不是有效的任务 JSON: %v", err)
+	}
+	return nil
+}
+
+// 把请求中出现的字段应用到任务上，全部校验通过才会修改。
+// 重复任务被标为完成时返回下一次任务；project 是需要新建的项目，由调用方在写入任务前保存
+func applyInput(item *TodoItem, input taskInput, items TodoList, projects []Project) (next *TodoItem, project *Project, err error) {
+	updated := item.clone()
+	if input.Title != nil {
+		updated.Title = strings.Join(strings.Fields(*input.Title), " ")
+		if updated.Title == "" {
+			return nil, nil, badRequest("标题不能为空")
+		}
+	}
+	if input.Notes != nil {
+		updated.Notes = normalizeNotes(*input.Notes)
+	}
+	if input.Priority != nil {
+		priority, err := ParsePriority(*input.Priority)
+		if err != nil {
+			return nil, nil, badRequest("%v", err)
+		}
+		updated.Priority = priority
+	}
+	if input.Deadline != nil {
+		if strings.TrimSpace(*input.Deadline) == "" {
+			updated.HasDeadline = false
+			updated.Deadline = time.Time{}
+			updated.Recurrence = Recurrence{}
+		} else {
+			deadline, err := parseImportTime(strings.TrimSpace(*input.Deadline))
+			if err != nil {
+				return nil, nil, badRequest("%v", err)
+			}
+			updated.HasDeadline = true
+			updated.Deadline = deadline
+		}
+	}
+	if input.Tags != nil {
+		updated.Tags = nil
+		for _, tag := range *input.Tags {
+			updated.Tags = appendTag(updated.Tags, tag)
+		}
+	}
+	if input.Parent != nil {
+		parent := strings.TrimSpace(*input.Parent)
+		switch {
+		case parent == "":
+		case indexOfID(items, parent) < 0:
+			return nil, nil, badRequest("父任务 %s 不存在", parent)
+		case parent == updated.id || slices.Contains(items.descendantIDs(updated.id), parent):
+			return nil, nil, badRequest("不能把任务移到自己或自己的子任务下")
+		}
+		updated.ParentID = parent
+	}
+	if input.Project != nil {
+		name := strings.TrimSpace(*input.Project)
+		switch index := findProjectByName(projects, name); {
+		case name == "" || isInboxName(name):
+			updated.ProjectID = inboxProjectID
+		case index >= 0:
+			updated.ProjectID = projects[index].id
+		default:
+			created := newProject(name, projects)
+			project = &created
+			updated.ProjectID = created.id
+		}
+	}
+
+	if input.Done != nil {
+		if *input.Done && !updated.Done {
+			if occurrence, ok := nextOccurrence(updated); ok {
+				next = &occurrence
+				updated.Recurrence = Recurrence{}
+			}
+		}
+		updated.Done = *input.Done
+	}
+	*item = updated
+	return next, project, nil
+}
+
+func (s *apiServer) saveNewProject(project *Project) error {
+	if project == nil {
+		return nil
+	}
+	return s.storage.SaveProject(*project)
+}
+
+func indexOfID(items TodoList, id string) int {
+	for i := range items {
+		if items[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// ====================== serve 子命令 ======================
+
+func cliServe(storage TaskStore, args []string) error {
+	fs := newFlagSet("serve")
+	addr := fs.String("addr", defaultServeAddr, "监听地址")
+	token := fs.String("token", os.Getenv("TODO_CLI_TOKEN"), "访问令牌，请求需带 Authorization: Bearer <令牌>，默认读取 TODO_CLI_TOKEN")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("多余的参数 %s", strings.Join(positional, " "))
+	}
+
+	// 没有令牌时只接受本机的请求，监听其他地址没有意义
+	if host, _, _ := net.SplitHostPort(*addr); *token == "" && !isLoopbackHost(host) {
+		return errors.New("监听的不是本机地址时必须设置 --token")
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           newAPIHandler(storage, *token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("正在监听 http://%s ，接口说明见 /openapi.json，按 Ctrl+C 退出\n", listener.Addr())
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// 请求中 Host 的主机名部分，不含端口
+func requestHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(r.Host, "["), "]")
+	}
+	return host
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ====================== 排序方式 ======================
+
+type SortMode int
+
+const (
+	SortByPriority SortMode = iota // 优先级 → 截止日期 → 标题（默认）
+	SortByDeadline                 // 截止日期 → 优先级 → 标题
+	SortByCreated                  // 创建时间，新的在前
+	SortByUpdated                  // 最后修改时间，新的在前
+	SortManual                     // 优先级 → 手动排列的顺序
+	SortByTitle                    // 标题字母顺序
+	sortModeCount
+)
+
+// 配置文件和命令行中使用的名称
+var sortModeNames = [...]string{"priority", "deadline", "created", "updated", "manual", "title"}
+
+// 界面中显示的名称
+var sortModeLabels = [...]string{"优先级", "截止日期", "创建时间", "更新时间", "手动", "标题"}
+
+func (s SortMode) String() string {
+	if s < 0 || s >= sortModeCount {
+		return "?"
+	}
+	return sortModeNames[s]
+}
+
+func (s SortMode) Label() string {
+	if s < 0 || s >= sortModeCount {
+		return "?"
+	}
+	return sortModeLabels[s]
+}
+
+func ParseSortMode(name string) (SortMode, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, candidate := range sortModeNames {
+		if name == candidate {
+			return SortMode(i), nil
+		}
+	}
+	return SortByPriority, fmt.Errorf("未知的排序方式 %q，可选 %s", name, strings.Join(sortModeNames[:], "/"))
+}
+
+// 在配置文件中以名称保存
+func (s SortMode) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *SortMode) UnmarshalText(text []byte) error {
+	mode, err := ParseSortMode(string(text))
+	if err != nil {
+		return err
+	}
+	*s = mode
+	return nil
+}
+
+// 按指定方式排序后按父子关系排列，任何方式下未完成的任务都在前
+func (tl TodoList) SortBy(mode SortMode) {
+	sort.SliceStable(tl, func(i, j int) bool {
+		return compareItems(mode, &tl[i], &tl[j]) < 0
+	})
+	tl.arrangeTree()
+}
+
+func compareItems(mode SortMode, a, b *TodoItem) int {
+	if a.Done != b.Done {
+		if !a.Done {
+			return -1
+		}
+		return 1
+	}
+
+	switch mode {
+	case SortByDeadline:
+		return cmp.Or(compareDeadline(a, b), comparePriority(a, b), compareTitle(a, b))
+	case SortByCreated:
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), compareTitle(a, b))
+	case SortByUpdated:
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), compareTitle(a, b))
+	case SortManual:
+		return cmp.Or(comparePriority(a, b), comparePosition(a, b), a.CreatedAt.Compare(b.CreatedAt))
+	case SortByTitle:
+		return cmp.Or(compareTitle(a, b), comparePriority(a, b))
+	}
+	return cmp.Or(comparePriority(a, b), compareDeadline(a, b), compareTitle(a, b))
+}
+
+// 优先级高的在前
+func comparePriority(a, b *TodoItem) int {
+	return cmp.Compare(b.Priority, a.Priority)
+}
+
+// 有截止日期的在前，截止日期早的在前
+func compareDeadline(a, b *TodoItem) int {
+	if a.HasDeadline != b.HasDeadline {
+		if a.HasDeadline {
+			return -1
+		}
+		return 1
+	}
+	return a.Deadline.Compare(b.Deadline)
+}
+
+// 调整过位置的在前，未调整过的（0）排在最后
+func comparePosition(a, b *TodoItem) int {
+	if (a.Position == 0) != (b.Position == 0) {
+		if a.Position != 0 {
+			return -1
+		}
+		return 1
+	}
+	return cmp.Compare(a.Position, b.Position)
+}
+
+// 按标题字母排序，忽略大小写
+func compareTitle(a, b *TodoItem) int {
+	return cmp.Or(
+		strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)),
+		strings.Compare(a.Title, b.Title),
+	)
+}
+
+// ====================== 模型操作 ======================
+
+// 按当前排序方式重新排列任务
+func (m *Model) sortItems() {
+	m.items.SortBy(m.sortMode)
+}
+
+// 切换到下一种排序方式
+func (m *Model) cycleSortMode() {
+	m.setSortMode((m.sortMode + 1) % sortModeCount)
+}
+
+// 设置排序方式并保存到配置中，下次启动时沿用
+func (m *Model) setSortMode(mode SortMode) {
+	m.sortMode = mode
+	m.sortItems()
+	m.findItemByID(m.selectedID)
+	m.statusLine = "排序：" + m.sortMode.Label()
+
+	config, err := LoadConfig()
+	if err != nil {
+		m.statusLine = err.Error()
+		return
+	}
+	config.Sort = m.sortMode
+	if err := SaveConfig(config); err != nil {
+		m.statusLine = err.Error()
+	}
+}
+
+// ====================== 手动排序 ======================
+
+// 在同一父任务下、完成状态和优先级都相同的任务之间上下移动当前任务，
+// delta 为 -1 上移、1 下移。不在手动排序时先切换过去
+func (m *Model) moveItem(delta int) {
+	index := m.currentIndex()
+	if index < 0 {
+		return
+	}
+	switched := m.sortMode != SortManual
+	if switched {
+		m.setSortMode(SortManual)
+		index = m.indexByID(m.selectedID)
+	}
+
+	current := m.items[index]
+	var group []TodoItem
+	pos := -1
+	for _, item := range m.items {
+		if item.ParentID != current.ParentID || item.Done != current.Done || item.Priority != current.Priority {
+			continue
+		}
+		if item.id == current.id {
+			pos = len(group)
+		}
+		group = append(group, item)
+	}
+
+	// 跳过被过滤掉的任务，与界面上相邻的任务交换
+	target := pos + delta
+	byID := m.indexMap()
+	for target >= 0 && target < len(group) && !m.isVisible(&group[target], byID) {
+		target += delta
+	}
+	if target < 0 || target >= len(group) {
+		if delta < 0 {
+			m.statusLine = "已经是同优先级任务中的第一个"
+		} else {
+			m.statusLine = "已经是同优先级任务中的最后一个"
+		}
+		return
+	}
+
+	moved := group[pos]
+	group = append(group[:pos], group[pos+1:]...)
+	group = append(group[:target], append([]TodoItem{moved}, group[target:]...)...)
+
+	// 整组重新编号，只写入位置有变化的任务
+	var upserts []TodoItem
+	for i, item := range group {
+		if item.Position == i+1 {
+			continue
+		}
+		item = item.clone()
+		item.Position = i + 1
+		item.touch()
+		upserts = append(upserts, item)
+	}
+	if m.applyChanges(upserts, nil) {
+		m.findItemByID(current.id)
+		// 刚切换排序方式时保留提示
+		if !switched {
+			m.statusLine = ""
+		}
+	}
+}
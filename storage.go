@@ -1,178 +1,203 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
-
-	"github.com/glebarez/sqlite"
-	"gorm.io/gorm"
 )
 
-// 数据库模型
-type TodoModel struct {
-	ID          string    `gorm:"primaryKey;size:50"`
-	Title       string    `gorm:"not null"`
-	Done        bool      `gorm:"default:false"`
-	Priority    int       `gorm:"not null"`
-	HasDeadline bool      `gorm:"default:false"`
-	Deadline    time.Time `gorm:"default:null"`
-	CreatedAt   time.Time `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
-}
-
-// 转换为内存模型
-func (tm *TodoModel) ToTodoItem() TodoItem {
-	return TodoItem{
-		Title:       tm.Title,
-		Done:        tm.Done,
-		Priority:    Priority(tm.Priority),
-		HasDeadline: tm.HasDeadline,
-		Deadline:    tm.Deadline,
-		id:          tm.ID,
-	}
-}
-
-// 从内存模型转换
-func TodoItemToModel(item *TodoItem) *TodoModel {
-	return &TodoModel{
-		ID:          item.id,
-		Title:       item.Title,
-		Done:        item.Done,
-		Priority:    int(item.Priority),
-		HasDeadline: item.HasDeadline,
-		Deadline:    item.Deadline,
-	}
+// ====================== 存储接口 ======================
+
+// 任务存储后端，交互界面和命令行都只通过该接口读写任务。
+// 新增或更新任务后，存储中的修订号为 item.Revision+1，调用方据此更新手中的副本。
+// 移入回收站和恢复也会把修订号加一，回收站中的任务只有带着其当前修订号的写入才能恢复
+type TaskStore interface {
+	// 加载全部任务，返回已排序的列表
+	Load() (TodoList, error)
+	// 新增单个任务，id 已存在时返回错误
+	Create(item TodoItem) error
+	// 更新单个任务，id 不存在时返回错误。
+	// item.Revision 须与存储中的修订号相同，否则返回 *ConflictError 且不写入；
+	// 任务已在回收站中时也返回 *ConflictError，Theirs 为回收站中的任务
+	Update(item TodoItem) error
+	// 新增或更新单个任务，更新时与 Update 一样检查修订号
+	Upsert(item TodoItem) error
+	// 按 id 把任务移入回收站，其子任务一并移入
+	Delete(id string) error
+	// 在一个事务中先把 deleteIDs（连同子任务）移入回收站，再新增或更新 upserts。
+	// 任何一个任务的修订号不符时返回 *ConflictError，全部不写入
+	Apply(upserts []TodoItem, deleteIDs []string) error
+	// 加载回收站中的任务，最近删除的在前
+	LoadTrash() (TodoList, error)
+	// 从回收站恢复任务，连同回收站中的子任务和上级任务
+	Restore(id string) error
+	// 从回收站彻底删除任务及其子任务
+	Purge(id string) error
+	// 彻底删除 before 之前移入回收站的任务，返回删除的数量
+	PurgeTrash(before time.Time) (int, error)
+	// 加载全部项目（包括已归档的）
+	LoadProjects() ([]Project, error)
+	// 新增或更新项目
+	SaveProject(project Project) error
+	// 数据的版本，任何写入（包括其他进程的写入）后都会变化，用于发现外部修改
+	Version() (int64, error)
+	Close() error
 }
 
-type Storage struct {
-	db *gorm.DB
-}
-
-// 创建新的存储实例
-func NewStorage() (*Storage, error) {
-	db, err := gorm.Open(sqlite.Open(dataFile()), &gorm.Config{})
+// 打开存储后端，并按配置清理回收站中过期的任务
+func OpenStore() (TaskStore, error) {
+	config, err := LoadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("打开数据库失败: %v", err)
+		return nil, err
 	}
-
-	// 自动迁移数据库结构
-	err = db.AutoMigrate(&TodoModel{})
+	store, err := openBackend()
 	if err != nil {
-		return nil, fmt.Errorf("迁移数据库失败: %v", err)
-	}
-
-	return &Storage{db: db}, nil
-}
-
-func (s *Storage) Load() (TodoList, string) {
-	var models []TodoModel
-	result := s.db.Order("done asc, priority desc, has_deadline desc, deadline asc, title asc").Find(&models)
-	if result.Error != nil {
-		return nil, fmt.Sprintf("加载失败: %v", result.Error)
+		return nil, err
 	}
-
-	items := make(TodoList, 0, len(models))
-	for _, model := range models {
-		items = append(items, model.ToTodoItem())
+	if config.TrashRetentionDays > 0 {
+		before := time.Now().AddDate(0, 0, -config.TrashRetentionDays)
+		if _, err := store.PurgeTrash(before); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("清理回收站失败: %v", err)
+		}
 	}
-
-	// 额外排序一次确保内存中的顺序正确
-	items.Sort()
-	return items, ""
+	return store, nil
 }
 
-func (s *Storage) Save(items TodoList) string {
-	// 首先对传入的列表进行排序
-	items.Sort() // 这里缺少了这个调用！
-
-	// 开启事务
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		return fmt.Sprintf("开始事务失败: %v", tx.Error)
-	}
-
-	// 获取所有现有ID
-	var existingIDs []string
-	if err := tx.Model(&TodoModel{}).Pluck("id", &existingIDs).Error; err != nil {
-		tx.Rollback()
-		return fmt.Sprintf("查询现有数据失败: %v", err)
-	}
-
-	// 将要保存的ID映射
-	savingIDs := make(map[string]bool)
-	for _, item := range items {
-		savingIDs[item.id] = true
-	}
-
-	// 删除不在新列表中的项目
-	for _, id := range existingIDs {
-		if !savingIDs[id] {
-			if err := tx.Where("id = ?", id).Delete(&TodoModel{}).Error; err != nil {
-				tx.Rollback()
-				return fmt.Sprintf("删除项目失败: %v", err)
-			}
+// 根据环境变量选择存储后端：
+//
+//	TODO_CLI_STORE  sqlite（默认）/ json / todotxt / memory
+//	TODO_CLI_PATH   数据文件路径，默认位于 ~/.todo_cli 下
+func openBackend() (TaskStore, error) {
+	backend := os.Getenv("TODO_CLI_STORE")
+	path := os.Getenv("TODO_CLI_PATH")
+
+	switch backend {
+	case "", "sqlite":
+		if path == "" {
+			path = dataFile("todo_cli.db")
+		}
+		store, err := NewSQLiteStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "json":
+		if path == "" {
+			path = dataFile("todo_cli.json")
 		}
+		store, err := NewJSONStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "todotxt":
+		if path == "" {
+			path = dataFile("todo.txt")
+		}
+		store, err := NewTodoTxtStore(path)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("未知的存储后端 %q，可选 sqlite/json/todotxt/memory", backend)
 	}
+}
 
-	// 更新或创建项目
-	for _, item := range items {
-		model := TodoItemToModel(&item)
-		var count int64
-		tx.Model(&TodoModel{}).Where("id = ?", item.id).Count(&count)
-
-		if count > 0 {
-			// 更新
-			if err := tx.Model(&TodoModel{}).Where("id = ?", item.id).Updates(map[string]interface{}{
-				"title":        item.Title,
-				"done":         item.Done,
-				"priority":     int(item.Priority),
-				"has_deadline": item.HasDeadline,
-				"deadline":     item.Deadline,
-			}).Error; err != nil {
-				tx.Rollback()
-				return fmt.Sprintf("更新项目失败: %v", err)
-			}
-		} else {
-			// 创建
-			if err := tx.Create(model).Error; err != nil {
-				tx.Rollback()
-				return fmt.Sprintf("创建项目失败: %v", err)
-			}
+// 根据文件的修改时间和大小计算版本，文件不存在时视为空
+func fileVersion(paths ...string) (int64, error) {
+	var version int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
 		}
+		if err != nil {
+			return 0, fmt.Errorf("读取 %s 失败: %v", path, err)
+		}
+		version = version*31 + info.ModTime().UnixNano() + info.Size()
 	}
+	return version, nil
+}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Sprintf("提交事务失败: %v", err)
-	}
+// 写入的任务已被其他地方修改，Theirs 为存储中的当前内容
+type ConflictError struct {
+	Theirs TodoItem
+}
 
-	return ""
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("任务「%s」已被其他地方修改", e.Theirs.Title)
 }
 
-// 关闭数据库连接
-func (s *Storage) Close() error {
-	sqlDB, err := s.db.DB()
-	if err != nil {
-		return err
+// 检查写入的修订号是否与存储中的一致
+func checkRevision(item, stored TodoItem) error {
+	if item.Revision != stored.Revision {
+		return &ConflictError{Theirs: stored.clone()}
 	}
-	return sqlDB.Close()
+	return nil
+}
+
+func errTaskNotFound(id string) error {
+	return fmt.Errorf("任务 %s 不存在", id)
+}
+
+func errNotInTrash(id string) error {
+	return fmt.Errorf("任务 %s 不在回收站中", id)
 }
 
-func dataFile() string {
+func dataFile(name string) string {
 	homePath, err := os.UserHomeDir()
 	if err != nil {
 		// 兜底保存在当前运行目录下
-		return "todo_cli.db"
+		return name
 	}
 	dir := filepath.Join(homePath, ".todo_cli")
 	err = ensureDir(dir)
 	if err != nil {
 		// 兜底保存在当前运行目录下
-		return "todo_cli.db"
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+// 先写临时文件再重命名，避免写到一半时损坏原文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".todo_cli-*.tmp")
+	if err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 %s 失败: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", path, err)
+	}
+	return nil
+}
+
+// 在 path 旁边的 .lock 文件上加排他锁后执行 fn。
+// 文件存储每次写入都是「读取 → 检查修订号 → 写回」，加锁后多个进程不会互相覆盖
+func withFileLock(path string, fn func() error) error {
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开锁文件失败: %v", err)
+	}
+	defer file.Close()
+	if err := lockFile(file); err != nil {
+		return fmt.Errorf("锁定 %s 失败: %v", path, err)
 	}
-	return filepath.Join(dir, "todo_cli.db")
+	defer unlockFile(file)
+	return fn()
 }
 
 func ensureDir(dirPath string) error {
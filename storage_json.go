@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ====================== JSON 文件存储 ======================
+
+// JSON 文件中的任务记录，字段保持可读，便于放进 dotfiles 仓库
+type taskRecord struct {
+	ID       string        `json:"id"`
+	Title    string        `json:"title"`
+	Notes    string        `json:"notes,omitempty"`
+	Done     bool          `json:"done"`
+	Priority string        `json:"priority"`
+	Deadline *time.Time    `json:"deadline,omitempty"`
+	Tags     []string      `json:"tags,omitempty"`
+	Project  string        `json:"project,omitempty"`
+	Parent   string        `json:"parent,omitempty"`
+	Repeat   *repeatRecord `json:"repeat,omitempty"`
+	Position int           `json:"position,omitempty"`
+	Created  *time.Time    `json:"created,omitempty"`
+	Updated  *time.Time    `json:"updated,omitempty"`
+	Deleted  *time.Time    `json:"deleted,omitempty"` // 移入回收站的时间
+	Revision int           `json:"revision,omitempty"`
+}
+
+type repeatRecord struct {
+	Frequency string     `json:"frequency"`
+	Interval  int        `json:"interval,omitempty"`
+	Weekdays  []string   `json:"weekdays,omitempty"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+type projectRecord struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Color    string `json:"color,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
+}
+
+type taskFile struct {
+	Projects []projectRecord `json:"projects,omitempty"`
+	Tasks    []taskRecord    `json:"tasks"`
+}
+
+func itemToRecord(item TodoItem) taskRecord {
+	record := taskRecord{
+		ID:       item.id,
+		Title:    item.Title,
+		Notes:    item.Notes,
+		Done:     item.Done,
+		Priority: item.Priority.String(),
+		Tags:     item.Tags,
+		Project:  item.ProjectID,
+		Parent:   item.ParentID,
+		Position: item.Position,
+		Revision: item.Revision,
+	}
+	if item.HasDeadline {
+		deadline := item.Deadline
+		record.Deadline = &deadline
+	}
+	if !item.CreatedAt.IsZero() {
+		createdAt := item.CreatedAt
+		record.Created = &createdAt
+	}
+	if !item.UpdatedAt.IsZero() {
+		updatedAt := item.UpdatedAt
+		record.Updated = &updatedAt
+	}
+	if item.Deleted() {
+		deletedAt := item.DeletedAt
+		record.Deleted = &deletedAt
+	}
+	if rule := item.Recurrence; rule.Enabled() {
+		record.Repeat = &repeatRecord{
+			Frequency: frequencyNames[rule.Frequency],
+			Interval:  rule.Interval,
+		}
+		for _, day := range rule.Weekdays {
+			record.Repeat.Weekdays = append(record.Repeat.Weekdays, strings.ToLower(day.String()[:3]))
+		}
+		if !rule.Until.IsZero() {
+			until := rule.Until
+			record.Repeat.Until = &until
+		}
+	}
+	return record
+}
+
+func recordToItem(record taskRecord) (TodoItem, error) {
+	priority, err := ParsePriority(record.Priority)
+	if err != nil {
+		return TodoItem{}, err
+	}
+	item := TodoItem{
+		Title:     record.Title,
+		Notes:     record.Notes,
+		Done:      record.Done,
+		Priority:  priority,
+		ProjectID: record.Project,
+		ParentID:  record.Parent,
+		Position:  record.Position,
+		Revision:  record.Revision,
+		id:        record.ID,
+	}
+	for _, tag := range record.Tags {
+		item.Tags = appendTag(item.Tags, tag)
+	}
+	if item.id == "" {
+		item.id = generateID()
+	}
+	if record.Deadline != nil {
+		item.HasDeadline = true
+		item.Deadline = *record.Deadline
+	}
+	if record.Created != nil {
+		item.CreatedAt = *record.Created
+	}
+	if record.Updated != nil {
+		item.UpdatedAt = *record.Updated
+	}
+	if record.Deleted != nil {
+		item.DeletedAt = *record.Deleted
+	}
+	if record.Repeat != nil {
+		if item.Recurrence.Frequency, err = ParseFrequency(record.Repeat.Frequency); err != nil {
+			return TodoItem{}, err
+		}
+		item.Recurrence.Interval = record.Repeat.Interval
+		if item.Recurrence.Weekdays, err = parseWeekdays(strings.Join(record.Repeat.Weekdays, ",")); err != nil {
+			return TodoItem{}, err
+		}
+		if record.Repeat.Until != nil {
+			item.Recurrence.Until = *record.Repeat.Until
+		}
+	}
+	return item, nil
+}
+
+func projectToRecord(project Project) projectRecord {
+	return projectRecord{
+		ID:       project.id,
+		Name:     project.Name,
+		Color:    project.Color,
+		Archived: project.Archived,
+	}
+}
+
+func recordToProject(record projectRecord) Project {
+	return Project{
+		Name:     record.Name,
+		Color:    record.Color,
+		Archived: record.Archived,
+		id:       record.ID,
+	}
+}
+
+// 以 JSON 文件为准的存储后端，每次修改后整体写回文件
+type JSONStore struct {
+	*MemoryStore
+	path string
+}
+
+func NewJSONStore(path string) (*JSONStore, error) {
+	store := &JSONStore{MemoryStore: NewMemoryStore(), path: path}
+	if err := store.read(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *JSONStore) Load() (TodoList, error) {
+	// 每次加载都重新读取文件，以便感知外部修改
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.Load()
+}
+
+func (s *JSONStore) Create(item TodoItem) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Create(item)
+	})
+}
+
+func (s *JSONStore) Update(item TodoItem) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Update(item)
+	})
+}
+
+func (s *JSONStore) Upsert(item TodoItem) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Upsert(item)
+	})
+}
+
+func (s *JSONStore) Delete(id string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Delete(id)
+	})
+}
+
+func (s *JSONStore) Apply(upserts []TodoItem, deleteIDs []string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Apply(upserts, deleteIDs)
+	})
+}
+
+func (s *JSONStore) LoadTrash() (TodoList, error) {
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.LoadTrash()
+}
+
+func (s *JSONStore) Restore(id string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Restore(id)
+	})
+}
+
+func (s *JSONStore) Purge(id string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Purge(id)
+	})
+}
+
+func (s *JSONStore) PurgeTrash(before time.Time) (int, error) {
+	count := 0
+	err := withFileLock(s.path, func() error {
+		if err := s.read(); err != nil {
+			return err
+		}
+		var err error
+		// 没有过期的任务时不写回文件
+		if count, err = s.MemoryStore.PurgeTrash(before); err != nil || count == 0 {
+			return err
+		}
+		return s.write()
+	})
+	return count, err
+}
+
+func (s *JSONStore) LoadProjects() ([]Project, error) {
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.LoadProjects()
+}
+
+func (s *JSONStore) SaveProject(project Project) error {
+	return s.modify(func() error {
+		return s.MemoryStore.SaveProject(project)
+	})
+}
+
+func (s *JSONStore) Version() (int64, error) {
+	return fileVersion(s.path)
+}
+
+// 先重新读取文件再修改并写回，避免覆盖其他进程的写入，修订号也以文件中的为准。
+// 整个过程持有文件锁，其他进程在此期间无法写入
+func (s *JSONStore) modify(fn func() error) error {
+	return withFileLock(s.path, func() error {
+		if err := s.read(); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		return s.write()
+	})
+}
+
+func (s *JSONStore) read() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// 文件不存在时视为空列表
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", s.path, err)
+	}
+
+	var file taskFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", s.path, err)
+	}
+
+	items := make(TodoList, 0, len(file.Tasks))
+	for _, record := range file.Tasks {
+		item, err := recordToItem(record)
+		if err != nil {
+			return fmt.Errorf("解析 %s 失败: %v", s.path, err)
+		}
+		items = append(items, item)
+	}
+
+	projects := make([]Project, 0, len(file.Projects))
+	for _, record := range file.Projects {
+		projects = append(projects, recordToProject(record))
+	}
+	s.MemoryStore.setProjects(projects)
+	s.MemoryStore.replaceAll(items)
+	return nil
+}
+
+func (s *JSONStore) write() error {
+	// 回收站中的任务也一并写入
+	items := s.MemoryStore.all()
+
+	projects, err := s.MemoryStore.LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	file := taskFile{Tasks: make([]taskRecord, 0, len(items))}
+	for _, project := range projects {
+		file.Projects = append(file.Projects, projectToRecord(project))
+	}
+	for _, item := range items {
+		file.Tasks = append(file.Tasks, itemToRecord(item))
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化失败: %v", err)
+	}
+	return writeFileAtomic(s.path, append(data, '\n'))
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ====================== 内存存储 ======================
+
+// 仅保存在内存中的存储后端，进程退出后数据丢失，适合测试和临时使用
+type MemoryStore struct {
+	mu       sync.Mutex
+	items    map[string]TodoItem
+	projects []Project
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string]TodoItem)}
+}
+
+func (s *MemoryStore) Load() (TodoList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make(TodoList, 0, len(s.items))
+	for _, item := range s.items {
+		if item.Deleted() {
+			continue
+		}
+		items = append(items, item.clone())
+	}
+	items.Sort()
+	return items, nil
+}
+
+func (s *MemoryStore) Create(item TodoItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.items[item.id]; ok {
+		return fmt.Errorf("任务 %s 已存在", item.id)
+	}
+	s.putLocked(item)
+	return nil
+}
+
+func (s *MemoryStore) Update(item TodoItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.items[item.id]
+	if !ok {
+		return errTaskNotFound(item.id)
+	}
+	// 已被移入回收站的任务同样作为冲突返回
+	if existing.Deleted() {
+		return &ConflictError{Theirs: existing.clone()}
+	}
+	if err := checkRevision(item, existing); err != nil {
+		return err
+	}
+	s.putLocked(item)
+	return nil
+}
+
+func (s *MemoryStore) Upsert(item TodoItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.items[item.id]; ok {
+		if err := checkRevision(item, existing); err != nil {
+			return err
+		}
+	}
+	s.putLocked(item)
+	return nil
+}
+
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trashLocked(id, time.Now())
+	return nil
+}
+
+func (s *MemoryStore) Apply(upserts []TodoItem, deleteIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 先检查全部修订号，有冲突时不做任何修改
+	for _, item := range upserts {
+		if existing, ok := s.items[item.id]; ok {
+			if err := checkRevision(item, existing); err != nil {
+				return err
+			}
+		}
+	}
+	now := time.Now()
+	for _, id := range deleteIDs {
+		s.trashLocked(id, now)
+	}
+	for _, item := range upserts {
+		s.putLocked(item)
+	}
+	return nil
+}
+
+// 写入任务并把修订号加一，调用方需持有锁
+func (s *MemoryStore) putLocked(item TodoItem) {
+	item = item.clone()
+	item.Revision++
+	s.items[item.id] = item
+}
+
+func (s *MemoryStore) LoadTrash() (TodoList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items TodoList
+	for _, item := range s.items {
+		if item.Deleted() {
+			items = append(items, item.clone())
+		}
+	}
+	items.sortTrash()
+	return items, nil
+}
+
+func (s *MemoryStore) Restore(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids, err := s.allLocked().restoreIDs(id)
+	if err != nil {
+		return err
+	}
+	for _, restoreID := range ids {
+		item := s.items[restoreID]
+		item.DeletedAt = time.Time{}
+		item.Revision++
+		s.items[restoreID] = item
+	}
+	return nil
+}
+
+func (s *MemoryStore) Purge(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, ok := s.items[id]; !ok || !item.Deleted() {
+		return errNotInTrash(id)
+	}
+	s.purgeLocked(id)
+	return nil
+}
+
+func (s *MemoryStore) PurgeTrash(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for id, item := range s.items {
+		if item.Deleted() && item.DeletedAt.Before(before) {
+			count += s.purgeLocked(id)
+		}
+	}
+	return count, nil
+}
+
+// 全部任务，包括回收站中的，调用方需持有锁
+func (s *MemoryStore) allLocked() TodoList {
+	items := make(TodoList, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	return items
+}
+
+// 全部任务，包括回收站中的
+func (s *MemoryStore) all() TodoList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make(TodoList, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item.clone())
+	}
+	items.Sort()
+	return items
+}
+
+// 整体替换全部任务，包括回收站中的
+func (s *MemoryStore) replaceAll(items TodoList) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]TodoItem, len(items))
+	for _, item := range items {
+		s.items[item.id] = item.clone()
+	}
+}
+
+// 把任务及其未删除的子任务移入回收站并把修订号加一，调用方需持有锁
+func (s *MemoryStore) trashLocked(id string, now time.Time) {
+	item, ok := s.items[id]
+	if !ok || item.Deleted() {
+		return
+	}
+	var live TodoList
+	for _, item := range s.items {
+		if !item.Deleted() {
+			live = append(live, item)
+		}
+	}
+	for _, trashID := range append(live.descendantIDs(id), id) {
+		item := s.items[trashID]
+		item.DeletedAt = now
+		item.Revision++
+		s.items[trashID] = item
+	}
+}
+
+// 彻底删除任务及其子任务，返回删除的数量，调用方需持有锁
+func (s *MemoryStore) purgeLocked(id string) int {
+	if _, ok := s.items[id]; !ok {
+		return 0
+	}
+	ids := append(s.allLocked().descendantIDs(id), id)
+	for _, purgeID := range ids {
+		delete(s.items, purgeID)
+	}
+	return len(ids)
+}
+
+func (s *MemoryStore) LoadProjects() ([]Project, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Project(nil), s.projects...), nil
+}
+
+func (s *MemoryStore) SaveProject(project Project) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.projects {
+		if s.projects[i].id == project.id {
+			s.projects[i] = project
+			return nil
+		}
+	}
+	s.projects = append(s.projects, project)
+	return nil
+}
+
+// 整体替换项目列表
+func (s *MemoryStore) setProjects(projects []Project) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.projects = append([]Project(nil), projects...)
+}
+
+// 内存中的数据只会被本进程修改，版本始终不变
+func (s *MemoryStore) Version() (int64, error) {
+	return 0, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 数据库模型
+type TodoModel struct {
+	ID          string     `gorm:"primaryKey;size:50"`
+	Title       string     `gorm:"not null"`
+	Notes       string     `gorm:"type:text"`
+	Done        bool       `gorm:"default:false"`
+	Priority    int        `gorm:"not null"`
+	HasDeadline bool       `gorm:"default:false"`
+	Deadline    time.Time  `gorm:"default:null"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime"`
+	Tags        []TagModel `gorm:"many2many:todo_tags"`
+	ProjectID   string     `gorm:"size:50;index"`
+	ParentID    string     `gorm:"size:50;index"`
+	Position    int        `gorm:"default:0"`          // 手动排序中的位置
+	Revision    int        `gorm:"not null;default:0"` // 修订号，每次写入加一
+
+	// 重复规则
+	RecurFrequency int       `gorm:"default:0"`
+	RecurInterval  int       `gorm:"default:0"`
+	RecurWeekdays  string    `gorm:"size:20"` // 逗号分隔的星期数字，0 为周日
+	RecurUntil     time.Time `gorm:"default:null"`
+
+	// 软删除：不为空时任务位于回收站，普通查询会自动排除
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// 项目表，任务通过 project_id 归属项目，为空时属于收件箱
+type ProjectModel struct {
+	ID        string    `gorm:"primaryKey;size:50"`
+	Name      string    `gorm:"uniqueIndex;not null"`
+	Color     string    `gorm:"size:20"`
+	Archived  bool      `gorm:"default:false"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// 只有一行的版本表，每次写入时加一，其他进程据此发现修改
+type StoreVersionModel struct {
+	ID      uint `gorm:"primaryKey"`
+	Version int64
+}
+
+func (StoreVersionModel) TableName() string {
+	return "store_version"
+}
+
+// 标签表，与任务多对多关联
+type TagModel struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"uniqueIndex;not null"`
+}
+
+// 转换为内存模型
+func (tm *TodoModel) ToTodoItem() TodoItem {
+	return TodoItem{
+		Title:       tm.Title,
+		Notes:       tm.Notes,
+		Done:        tm.Done,
+		Priority:    Priority(tm.Priority),
+		HasDeadline: tm.HasDeadline,
+		Deadline:    tm.Deadline,
+		Tags:        tagNames(tm.Tags),
+		ProjectID:   tm.ProjectID,
+		ParentID:    tm.ParentID,
+		Position:    tm.Position,
+		Recurrence: Recurrence{
+			Frequency: Frequency(tm.RecurFrequency),
+			Interval:  tm.RecurInterval,
+			Weekdays:  decodeWeekdays(tm.RecurWeekdays),
+			Until:     tm.RecurUntil,
+		},
+		CreatedAt: tm.CreatedAt,
+		UpdatedAt: tm.UpdatedAt,
+		DeletedAt: deletedTime(tm.DeletedAt),
+		Revision:  tm.Revision,
+		id:        tm.ID,
+	}
+}
+
+func deletedTime(deletedAt gorm.DeletedAt) time.Time {
+	if !deletedAt.Valid {
+		return time.Time{}
+	}
+	return deletedAt.Time
+}
+
+func encodeWeekdays(days []time.Weekday) string {
+	parts := make([]string, 0, len(days))
+	for _, day := range days {
+		parts = append(parts, strconv.Itoa(int(day)))
+	}
+	return strings.Join(parts, ",")
+}
+
+func decodeWeekdays(s string) []time.Weekday {
+	var days []time.Weekday
+	for _, part := range strings.Split(s, ",") {
+		if n, err := strconv.Atoi(part); err == nil && n >= 0 && n <= 6 {
+			days = append(days, time.Weekday(n))
+		}
+	}
+	return days
+}
+
+func tagNames(tags []TagModel) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 从内存模型转换，标签单独通过 saveTags 写入
+func TodoItemToModel(item *TodoItem) *TodoModel {
+	return &TodoModel{
+		ID:          item.id,
+		Title:       item.Title,
+		Notes:       item.Notes,
+		Done:        item.Done,
+		Priority:    int(item.Priority),
+		HasDeadline: item.HasDeadline,
+		Deadline:    item.Deadline,
+		ProjectID:   item.ProjectID,
+		ParentID:    item.ParentID,
+		Position:    item.Position,
+
+		RecurFrequency: int(item.Recurrence.Frequency),
+		RecurInterval:  item.Recurrence.Interval,
+		RecurWeekdays:  encodeWeekdays(item.Recurrence.Weekdays),
+		RecurUntil:     item.Recurrence.Until,
+
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+		DeletedAt: gorm.DeletedAt{Time: item.DeletedAt, Valid: item.Deleted()},
+		Revision:  item.Revision,
+	}
+}
+
+type SQLiteStore struct {
+	db *gorm.DB
+}
+
+// 创建新的 SQLite 存储实例
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %v", err)
+	}
+
+	// 自动迁移数据库结构
+	err = db.AutoMigrate(&TodoModel{}, &TagModel{}, &ProjectModel{}, &StoreVersionModel{})
+	if err != nil {
+		return nil, fmt.Errorf("迁移数据库失败: %v", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Load() (TodoList, error) {
+	var models []TodoModel
+	result := s.db.Preload("Tags").Order("created_at asc").Find(&models)
+	if result.Error != nil {
+		return nil, fmt.Errorf("加载失败: %v", result.Error)
+	}
+
+	items := make(TodoList, 0, len(models))
+	for _, model := range models {
+		items = append(items, model.ToTodoItem())
+	}
+
+	// 排序方式可以在界面中切换，这里按默认方式排序，界面再按需重排
+	items.Sort()
+	return items, nil
+}
+
+func (s *SQLiteStore) Create(item TodoItem) error {
+	return s.write(func(tx *gorm.DB) error {
+		model := TodoItemToModel(&item)
+		model.Revision++
+		if err := tx.Create(model).Error; err != nil {
+			return fmt.Errorf("创建项目失败: %v", err)
+		}
+		return saveTags(tx, item.id, item.Tags)
+	})
+}
+
+func (s *SQLiteStore) Update(item TodoItem) error {
+	return s.write(func(tx *gorm.DB) error {
+		// 只在修订号与读取时相同时更新
+		result := tx.Model(&TodoModel{}).Where("id = ? AND revision = ?", item.id, item.Revision).Updates(todoColumns(&item))
+		if result.Error != nil {
+			return fmt.Errorf("更新项目失败: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			// 已被移入回收站的任务同样作为冲突返回
+			stored, err := loadTodo(tx.Unscoped(), item.id)
+			if err != nil {
+				return err
+			}
+			if stored == nil {
+				return errTaskNotFound(item.id)
+			}
+			return &ConflictError{Theirs: *stored}
+		}
+		return saveTags(tx, item.id, item.Tags)
+	})
+}
+
+func (s *SQLiteStore) Upsert(item TodoItem) error {
+	return s.write(func(tx *gorm.DB) error {
+		return upsertTodo(tx, &item)
+	})
+}
+
+func (s *SQLiteStore) Delete(id string) error {
+	return s.write(func(tx *gorm.DB) error {
+		return trashTodo(tx, id)
+	})
+}
+
+func (s *SQLiteStore) Apply(upserts []TodoItem, deleteIDs []string) error {
+	return s.write(func(tx *gorm.DB) error {
+		for _, id := range deleteIDs {
+			if err := trashTodo(tx, id); err != nil {
+				return err
+			}
+		}
+		for i := range upserts {
+			if err := upsertTodo(tx, &upserts[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) LoadTrash() (TodoList, error) {
+	var models []TodoModel
+	result := s.db.Unscoped().Preload("Tags").Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&models)
+	if result.Error != nil {
+		return nil, fmt.Errorf("加载回收站失败: %v", result.Error)
+	}
+
+	items := make(TodoList, 0, len(models))
+	for _, model := range models {
+		items = append(items, model.ToTodoItem())
+	}
+	items.sortTrash()
+	return items, nil
+}
+
+func (s *SQLiteStore) Restore(id string) error {
+	return s.write(func(tx *gorm.DB) error {
+		tree, err := loadTree(tx.Unscoped())
+		if err != nil {
+			return err
+		}
+		ids, err := tree.restoreIDs(id)
+		if err != nil {
+			return err
+		}
+		result := tx.Unscoped().Model(&TodoModel{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"revision":   gorm.Expr("revision + 1"),
+		})
+		if result.Error != nil {
+			return fmt.Errorf("恢复任务失败: %v", result.Error)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) Purge(id string) error {
+	return s.write(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&TodoModel{}).Where("id = ? AND deleted_at IS NOT NULL", id).Count(&count).Error; err != nil {
+			return fmt.Errorf("查询回收站失败: %v", err)
+		}
+		if count == 0 {
+			return errNotInTrash(id)
+		}
+		_, err := purgeTodo(tx, id)
+		return err
+	})
+}
+
+func (s *SQLiteStore) PurgeTrash(before time.Time) (int, error) {
+	count := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := tx.Unscoped().Model(&TodoModel{}).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("查询回收站失败: %v", err)
+		}
+		purged := make(map[string]bool)
+		for _, id := range ids {
+			// 子任务可能已经随父任务一起删除
+			if purged[id] {
+				continue
+			}
+			removed, err := purgeTodo(tx, id)
+			if err != nil {
+				return err
+			}
+			for _, removedID := range removed {
+				purged[removedID] = true
+			}
+		}
+		count = len(purged)
+		if count == 0 {
+			return nil
+		}
+		return bumpVersion(tx)
+	})
+	return count, err
+}
+
+func (s *SQLiteStore) LoadProjects() ([]Project, error) {
+	var models []ProjectModel
+	if err := s.db.Order("created_at asc").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("加载项目失败: %v", err)
+	}
+
+	projects := make([]Project, 0, len(models))
+	for _, model := range models {
+		projects = append(projects, Project{
+			Name:     model.Name,
+			Color:    model.Color,
+			Archived: model.Archived,
+			id:       model.ID,
+		})
+	}
+	return projects, nil
+}
+
+func (s *SQLiteStore) SaveProject(project Project) error {
+	model := ProjectModel{
+		ID:       project.id,
+		Name:     project.Name,
+		Color:    project.Color,
+		Archived: project.Archived,
+	}
+	return s.write(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "color", "archived"}),
+		}).Create(&model).Error
+		if err != nil {
+			return fmt.Errorf("保存项目失败: %v", err)
+		}
+		return nil
+	})
+}
+
+func (s *SQLiteStore) Version() (int64, error) {
+	var rows []StoreVersionModel
+	if err := s.db.Limit(1).Find(&rows).Error; err != nil {
+		return 0, fmt.Errorf("读取数据版本失败: %v", err)
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return rows[0].Version, nil
+}
+
+// 在事务中执行写入，并增加数据版本
+func (s *SQLiteStore) write(fn func(tx *gorm.DB) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return bumpVersion(tx)
+	})
+}
+
+func bumpVersion(tx *gorm.DB) error {
+	err := tx.Exec("INSERT INTO store_version (id, version) VALUES (1, 1) ON CONFLICT(id) DO UPDATE SET version = version + 1").Error
+	if err != nil {
+		return fmt.Errorf("更新数据版本失败: %v", err)
+	}
+	return nil
+}
+
+// 关闭数据库连接
+func (s *SQLiteStore) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// 更新时写入的列，与 upsertTodo 中的列保持一致
+func todoColumns(item *TodoItem) map[string]interface{} {
+	return map[string]interface{}{
+		"title":        item.Title,
+		"notes":        item.Notes,
+		"done":         item.Done,
+		"priority":     int(item.Priority),
+		"has_deadline": item.HasDeadline,
+		"deadline":     item.Deadline,
+		"project_id":   item.ProjectID,
+		"parent_id":    item.ParentID,
+		"position":     item.Position,
+
+		"recur_frequency": int(item.Recurrence.Frequency),
+		"recur_interval":  item.Recurrence.Interval,
+		"recur_weekdays":  encodeWeekdays(item.Recurrence.Weekdays),
+		"recur_until":     item.Recurrence.Until,
+		"updated_at":      item.UpdatedAt,
+		"revision":        item.Revision + 1,
+	}
+}
+
+// 存在则更新，不存在则创建，只需一条语句
+func upsertTodo(tx *gorm.DB, item *TodoItem) error {
+	columns := []string{
+		"title", "notes", "done", "priority", "has_deadline", "deadline", "project_id", "parent_id",
+		"position", "recur_frequency", "recur_interval", "recur_weekdays", "recur_until", "updated_at",
+		"deleted_at", "revision",
+	}
+	// 已存在（包括回收站中）的任务先检查修订号
+	stored, err := loadTodo(tx.Unscoped(), item.id)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		if err := checkRevision(*item, *stored); err != nil {
+			return err
+		}
+	}
+	model := TodoItemToModel(item)
+	model.Revision++
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(model).Error
+	if err != nil {
+		return fmt.Errorf("保存项目失败: %v", err)
+	}
+	return saveTags(tx, item.id, item.Tags)
+}
+
+// 读取单个任务，不存在时返回 nil；需要包括回收站时传入 Unscoped 的 tx
+func loadTodo(tx *gorm.DB, id string) (*TodoItem, error) {
+	var models []TodoModel
+	if err := tx.Preload("Tags").Where("id = ?", id).Limit(1).Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询任务失败: %v", err)
+	}
+	if len(models) == 0 {
+		return nil, nil
+	}
+	item := models[0].ToTodoItem()
+	return &item, nil
+}
+
+// 只读取 id、父任务和删除时间，用于计算子任务；需要包括回收站时传入 Unscoped 的 tx
+func loadTree(tx *gorm.DB) (TodoList, error) {
+	var models []TodoModel
+	if err := tx.Select("id", "parent_id", "deleted_at").Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("查询子任务失败: %v", err)
+	}
+	tree := make(TodoList, 0, len(models))
+	for _, model := range models {
+		tree = append(tree, TodoItem{ParentID: model.ParentID, DeletedAt: deletedTime(model.DeletedAt), id: model.ID})
+	}
+	return tree, nil
+}
+
+// 把任务及其全部子任务移入回收站，标签保留以便恢复。
+// 修订号加一，之前读到的副本再写入时会发生冲突，而不会把任务从回收站中带回来
+func trashTodo(tx *gorm.DB, id string) error {
+	tree, err := loadTree(tx)
+	if err != nil {
+		return err
+	}
+	ids := append(tree.descendantIDs(id), id)
+	err = tx.Model(&TodoModel{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"deleted_at": time.Now(),
+		"revision":   gorm.Expr("revision + 1"),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("删除项目失败: %v", err)
+	}
+	return nil
+}
+
+// 彻底删除任务及其全部子任务，返回删除的任务ID
+func purgeTodo(tx *gorm.DB, id string) ([]string, error) {
+	tree, err := loadTree(tx.Unscoped())
+	if err != nil {
+		return nil, err
+	}
+	ids := append(tree.descendantIDs(id), id)
+	for _, purgeID := range ids {
+		if err := purgeSingleTodo(tx, purgeID); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// 彻底删除单个任务及其标签关联
+func purgeSingleTodo(tx *gorm.DB, id string) error {
+	if err := tx.Model(&TodoModel{ID: id}).Association("Tags").Clear(); err != nil {
+		return fmt.Errorf("删除标签关联失败: %v", err)
+	}
+	if err := tx.Unscoped().Where("id = ?", id).Delete(&TodoModel{}).Error; err != nil {
+		return fmt.Errorf("删除项目失败: %v", err)
+	}
+	return nil
+}
+
+// 用给定的标签替换任务现有的标签，不存在的标签会被创建
+func saveTags(tx *gorm.DB, todoID string, names []string) error {
+	tags := make([]TagModel, 0, len(names))
+	for _, name := range names {
+		tag := TagModel{Name: name}
+		if err := tx.Where(TagModel{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return fmt.Errorf("保存标签失败: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := tx.Model(&TodoModel{ID: todoID}).Association("Tags").Replace(tags); err != nil {
+		return fmt.Errorf("保存标签失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// 更新单个任务的耗时不应随任务总数增长
+func BenchmarkUpdate(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("items=%d", size), func(b *testing.B) {
+			store, err := NewSQLiteStore(filepath.Join(b.TempDir(), "bench.db"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer store.Close()
+
+			items := make([]TodoItem, size)
+			for i := range items {
+				items[i] = TodoItem{Title: fmt.Sprintf("任务 %d", i), Priority: PriorityMedium, id: generateID()}
+				items[i].touch()
+			}
+			if err := store.Apply(items, nil); err != nil {
+				b.Fatal(err)
+			}
+
+			item := items[size/2]
+			item.Revision = 1
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				item.Done = !item.Done
+				item.touch()
+				if err := store.Update(item); err != nil {
+					b.Fatal(err)
+				}
+				item.Revision++
+			}
+		})
+	}
+}
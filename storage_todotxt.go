@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ====================== todo.txt 文件存储 ======================
+
+// 以 todo.txt 文件为准的存储后端，便于与手机上的 todo.txt 应用同步。
+// todo.txt 无法表示的内容（备注、重复规则、手动排序、回收站、项目颜色等）
+// 保存在旁边的 .meta.json 文件中，以 id: 与 todo.txt 中的任务对应：
+//
+//   - 在其他应用中修改的标题、优先级、截止日期等以 todo.txt 为准
+//   - 其他应用新增的任务（没有 id:）会分配 id 并立即写回
+//   - 从 todo.txt 中删掉的任务移入回收站
+type TodoTxtStore struct {
+	*MemoryStore
+	path  string
+	order []string // todo.txt 中任务的顺序，写回时保持不变，新任务追加在末尾
+}
+
+func NewTodoTxtStore(path string) (*TodoTxtStore, error) {
+	store := &TodoTxtStore{MemoryStore: NewMemoryStore(), path: path}
+	if err := store.refresh(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *TodoTxtStore) Load() (TodoList, error) {
+	// 每次加载都重新读取文件，以便感知其他应用的修改
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.Load()
+}
+
+func (s *TodoTxtStore) Create(item TodoItem) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Create(item)
+	})
+}
+
+func (s *TodoTxtStore) Update(item TodoItem) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Update(item)
+	})
+}
+
+func (s *TodoTxtStore) Upsert(item TodoItem) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Upsert(item)
+	})
+}
+
+func (s *TodoTxtStore) Delete(id string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Delete(id)
+	})
+}
+
+func (s *TodoTxtStore) Apply(upserts []TodoItem, deleteIDs []string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Apply(upserts, deleteIDs)
+	})
+}
+
+func (s *TodoTxtStore) LoadTrash() (TodoList, error) {
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.LoadTrash()
+}
+
+func (s *TodoTxtStore) Restore(id string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Restore(id)
+	})
+}
+
+func (s *TodoTxtStore) Purge(id string) error {
+	return s.modify(func() error {
+		return s.MemoryStore.Purge(id)
+	})
+}
+
+func (s *TodoTxtStore) PurgeTrash(before time.Time) (int, error) {
+	count := 0
+	err := withFileLock(s.path, func() error {
+		if err := s.read(); err != nil {
+			return err
+		}
+		var err error
+		// 没有过期的任务时不写回文件
+		if count, err = s.MemoryStore.PurgeTrash(before); err != nil || count == 0 {
+			return err
+		}
+		return s.write()
+	})
+	return count, err
+}
+
+func (s *TodoTxtStore) LoadProjects() ([]Project, error) {
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s.MemoryStore.LoadProjects()
+}
+
+func (s *TodoTxtStore) SaveProject(project Project) error {
+	return s.modify(func() error {
+		return s.MemoryStore.SaveProject(project)
+	})
+}
+
+func (s *TodoTxtStore) Version() (int64, error) {
+	return fileVersion(s.path, s.metaPath())
+}
+
+func (s *TodoTxtStore) metaPath() string {
+	return s.path + ".meta.json"
+}
+
+// 先重新读取文件再修改并写回，避免覆盖其他应用的修改。
+// 整个过程持有文件锁，本程序的其他进程在此期间无法写入
+func (s *TodoTxtStore) modify(fn func() error) error {
+	return withFileLock(s.path, func() error {
+		if err := s.read(); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		return s.write()
+	})
+}
+
+// 重新读取文件。读取时可能为其他应用新增的任务补上 id 并写回，因此同样加锁
+func (s *TodoTxtStore) refresh() error {
+	return withFileLock(s.path, s.read)
+}
+
+func (s *TodoTxtStore) read() error {
+	// 先读取附加信息
+	var meta taskFile
+	data, err := os.ReadFile(s.metaPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("读取 %s 失败: %v", s.metaPath(), err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("解析 %s 失败: %v", s.metaPath(), err)
+		}
+	}
+	known := make(map[string]TodoItem, len(meta.Tasks))
+	for _, record := range meta.Tasks {
+		item, err := recordToItem(record)
+		if err != nil {
+			return fmt.Errorf("解析 %s 失败: %v", s.metaPath(), err)
+		}
+		known[item.id] = item
+	}
+	projects := make([]Project, 0, len(meta.Projects))
+	for _, record := range meta.Projects {
+		projects = append(projects, recordToProject(record))
+	}
+
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// 文件不存在时视为空列表
+		s.MemoryStore.setProjects(projects)
+		s.MemoryStore.replaceAll(nil)
+		s.order = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", s.path, err)
+	}
+	defer file.Close()
+
+	var items TodoList
+	var order []string
+	seen := make(map[string]bool)
+	dirty := false
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		task, err := parseTodoTxtLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("解析 %s 失败: 第 %d 行：%v", s.path, lineNo, err)
+		}
+
+		parsed := task.item
+		if task.project != "" && !isInboxName(task.project) {
+			index := findTodoTxtProject(projects, task.project)
+			if index < 0 {
+				projects = append(projects, newProject(task.project, projects))
+				index = len(projects) - 1
+				dirty = true
+			}
+			parsed.ProjectID = projects[index].id
+		}
+		if parsed.id == "" || seen[parsed.id] {
+			parsed.id = generateID()
+			dirty = true
+		}
+		seen[parsed.id] = true
+
+		item, ok := known[parsed.id]
+		if !ok || item.Deleted() {
+			// 其他应用新增的任务，记录到附加信息中
+			if parsed.UpdatedAt.IsZero() {
+				parsed.touch()
+			} else if parsed.CreatedAt.IsZero() {
+				parsed.CreatedAt = parsed.UpdatedAt
+			}
+			item = parsed
+			dirty = true
+		} else if merged, changed := mergeTodoTxtItem(item, parsed); changed {
+			item = merged
+			dirty = true
+		}
+		items = append(items, item)
+		order = append(order, item.id)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", s.path, err)
+	}
+
+	// 回收站中的任务保留，从 todo.txt 中删掉的任务移入回收站
+	now := time.Now()
+	for id, item := range known {
+		if seen[id] {
+			continue
+		}
+		if !item.Deleted() {
+			item.DeletedAt = now
+			dirty = true
+		}
+		items = append(items, item)
+	}
+
+	s.MemoryStore.setProjects(projects)
+	s.MemoryStore.replaceAll(items)
+	s.order = order
+	if dirty {
+		return s.write()
+	}
+	return nil
+}
+
+// 用 todo.txt 中的内容更新已知的任务，截止日期的日期没变时保留原来的时间。
+// 在其他应用中修改过时更新修改时间和修订号，并返回 true
+func mergeTodoTxtItem(known, parsed TodoItem) (TodoItem, bool) {
+	item := known.clone()
+	item.Title = parsed.Title
+	item.Done = parsed.Done
+	item.Priority = parsed.Priority
+	item.Tags = parsed.Tags
+	item.ProjectID = parsed.ProjectID
+	item.ParentID = parsed.ParentID
+	switch {
+	case !parsed.HasDeadline:
+		item.HasDeadline = false
+		item.Deadline = time.Time{}
+		item.Recurrence = Recurrence{}
+	case !item.HasDeadline || !sameDay(item.Deadline, parsed.Deadline):
+		item.HasDeadline = true
+		item.Deadline = parsed.Deadline
+	}
+	if !parsed.CreatedAt.IsZero() && !sameDay(item.CreatedAt, parsed.CreatedAt) {
+		item.CreatedAt = parsed.CreatedAt
+	}
+	if formatTodoTxtLine(item, "") == formatTodoTxtLine(known, "") && item.ProjectID == known.ProjectID {
+		return known, false
+	}
+	item.touch()
+	item.Revision++
+	return item, true
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Local().Format(todoTxtDateLayout) == b.Local().Format(todoTxtDateLayout)
+}
+
+func (s *TodoTxtStore) write() error {
+	// 回收站中的任务只写入附加信息
+	items := s.MemoryStore.all()
+	projects, err := s.MemoryStore.LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]TodoItem, len(items))
+	for _, item := range items {
+		byID[item.id] = item
+	}
+	var lines, order []string
+	written := make(map[string]bool, len(items))
+	writeLine := func(item TodoItem) {
+		project := ""
+		if item.ProjectID != inboxProjectID {
+			project = projectName(projects, item.ProjectID)
+		}
+		lines = append(lines, formatTodoTxtLine(item, project))
+		order = append(order, item.id)
+		written[item.id] = true
+	}
+	for _, id := range s.order {
+		if item, ok := byID[id]; ok && !item.Deleted() {
+			writeLine(item)
+		}
+	}
+	for _, item := range items {
+		if !item.Deleted() && !written[item.id] {
+			writeLine(item)
+		}
+	}
+
+	meta := taskFile{Tasks: make([]taskRecord, 0, len(items))}
+	for _, project := range projects {
+		meta.Projects = append(meta.Projects, projectToRecord(project))
+	}
+	for _, item := range items {
+		meta.Tasks = append(meta.Tasks, itemToRecord(item))
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化失败: %v", err)
+	}
+
+	text := strings.Join(lines, "\n")
+	if text != "" {
+		text += "\n"
+	}
+	if err := writeFileAtomic(s.path, []byte(text)); err != nil {
+		return err
+	}
+	s.order = order
+	return writeFileAtomic(s.metaPath(), append(data, '\n'))
+}
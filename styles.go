@@ -21,6 +21,7 @@ type Styles struct {
 	TableBorder  lipgloss.Style
 	TableCell    lipgloss.Style
 	SelectedRow  lipgloss.Style // 添加选中行样式
+	Tag          lipgloss.Style
 }
 
 func NewStyles() *Styles {
@@ -41,5 +42,6 @@ func NewStyles() *Styles {
 		TableBorder:  lipgloss.NewStyle().Foreground(lipgloss.Color("236")),
 		TableCell:    lipgloss.NewStyle().Padding(0, 1),
 		SelectedRow:  lipgloss.NewStyle().Background(lipgloss.Color("235")), // 选中行背景色
+		Tag:          lipgloss.NewStyle().Foreground(lipgloss.Color("105")),
 	}
 }
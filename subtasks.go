@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+)
+
+// ====================== 子任务 ======================
+
+// 按树形重新排列：每个任务后面紧跟它的子任务，兄弟任务之间保持已排好的顺序。
+// 父任务不存在的任务视为顶层任务。
+func (tl TodoList) arrangeTree() {
+	present := make(map[string]bool, len(tl))
+	for _, item := range tl {
+		present[item.id] = true
+	}
+
+	children := make(map[string][]TodoItem)
+	var roots []TodoItem
+	for _, item := range tl {
+		if item.ParentID != "" && item.ParentID != item.id && present[item.ParentID] {
+			children[item.ParentID] = append(children[item.ParentID], item)
+		} else {
+			roots = append(roots, item)
+		}
+	}
+
+	ordered := make(TodoList, 0, len(tl))
+	visited := make(map[string]bool, len(tl))
+	var walk func(item TodoItem)
+	walk = func(item TodoItem) {
+		if visited[item.id] {
+			return
+		}
+		visited[item.id] = true
+		ordered = append(ordered, item)
+		for _, child := range children[item.id] {
+			walk(child)
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+	// 父子关系成环时这些任务不会被遍历到，追加在末尾避免丢失
+	for _, item := range tl {
+		if !visited[item.id] {
+			walk(item)
+		}
+	}
+	copy(tl, ordered)
+}
+
+// 每个任务的子任务数量
+func (tl TodoList) childCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, item := range tl {
+		if item.ParentID != "" {
+			counts[item.ParentID]++
+		}
+	}
+	return counts
+}
+
+// 每个任务在树中的深度，顶层为 0
+func (tl TodoList) depths() map[string]int {
+	depths := make(map[string]int, len(tl))
+	// 列表已按树形排列，父任务总在子任务之前
+	for _, item := range tl {
+		if parentDepth, ok := depths[item.ParentID]; ok && item.ParentID != "" {
+			depths[item.id] = parentDepth + 1
+		} else {
+			depths[item.id] = 0
+		}
+	}
+	return depths
+}
+
+// 任务的全部后代 id（不含自身）
+func (tl TodoList) descendantIDs(id string) []string {
+	children := make(map[string][]string)
+	for _, item := range tl {
+		if item.ParentID != "" {
+			children[item.ParentID] = append(children[item.ParentID], item.id)
+		}
+	}
+
+	var result []string
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			result = append(result, child)
+			queue = append(queue, child)
+		}
+	}
+	return result
+}
+
+// 统计叶子任务的完成情况，有子任务的父任务由子任务体现进度
+func leafProgress(items []TodoItem, childCounts map[string]int) (done, total int) {
+	for _, item := range items {
+		if childCounts[item.id] > 0 {
+			continue
+		}
+		total++
+		if item.Done {
+			done++
+		}
+	}
+	return done, total
+}
+
+// ====================== 子任务操作 ======================
+
+// 是否有祖先任务被折叠，byID 为 indexMap 的结果
+func (m *Model) isCollapsedAway(item *TodoItem, byID map[string]int) bool {
+	if len(m.collapsed) == 0 {
+		return false
+	}
+	visited := make(map[string]bool)
+	for parentID := item.ParentID; parentID != "" && !visited[parentID]; {
+		visited[parentID] = true
+		if m.collapsed[parentID] {
+			return true
+		}
+		index, ok := byID[parentID]
+		if !ok {
+			return false
+		}
+		parentID = m.items[index].ParentID
+	}
+	return false
+}
+
+func (m *Model) startAddingSubtask() {
+	index := m.currentIndex()
+	if index < 0 {
+		return
+	}
+	parent := m.items[index]
+	m.startAddingItem()
+	m.draftItem.ParentID = parent.id
+	m.draftItem.ProjectID = parent.ProjectID
+	m.input.Placeholder = "子任务内容"
+	m.statusLine = fmt.Sprintf("为「%s」添加子任务，回车确认，Esc 取消", parent.Title)
+}
+
+// 折叠当前任务；已折叠或没有子任务时跳到父任务
+func (m *Model) collapseCurrent() {
+	index := m.currentIndex()
+	if index < 0 {
+		return
+	}
+	item := m.items[index]
+	if m.items.childCounts()[item.id] > 0 && !m.collapsed[item.id] {
+		m.collapsed[item.id] = true
+		m.findItemByID(item.id)
+		return
+	}
+	if item.ParentID != "" && m.indexByID(item.ParentID) >= 0 {
+		m.findItemByID(item.ParentID)
+	}
+}
+
+func (m *Model) expandCurrent() {
+	index := m.currentIndex()
+	if index < 0 {
+		return
+	}
+	delete(m.collapsed, m.items[index].id)
+	m.findItemByID(m.items[index].id)
+}
+
+// 未完成的后代任务
+func (m *Model) pendingDescendants(id string) []string {
+	var pending []string
+	for _, childID := range m.items.descendantIDs(id) {
+		if index := m.indexByID(childID); index >= 0 && !m.items[index].Done {
+			pending = append(pending, childID)
+		}
+	}
+	return pending
+}
+
+// 批量设置完成状态
+func (m *Model) setDone(ids []string, done bool) {
+	for _, id := range ids {
+		index := m.indexByID(id)
+		if index < 0 || m.items[index].Done == done {
+			continue
+		}
+		m.items[index].Done = done
+		m.updateItem(m.items[index])
+		if m.statusLine != "" {
+			return
+		}
+		if done {
+			m.scheduleNextOccurrence(m.items[m.indexByID(id)])
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ====================== 标签 ======================
+
+// 从输入中拆出 #标签，返回去掉标签后的标题和去重后的标签。
+// 只有 # 后以字母开头的词才是标签，"修复 #42" 这类编号留在标题中
+func parseTitleTags(input string) (string, []string) {
+	var words []string
+	var tags []string
+	for _, word := range strings.Fields(input) {
+		if tag, ok := strings.CutPrefix(word, "#"); ok && isTitleTag(tag) {
+			tags = appendTag(tags, tag)
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), tags
+}
+
+// 能否以 #标签 的形式写在标题中：以字母（包括汉字）开头
+func isTitleTag(tag string) bool {
+	r, _ := utf8.DecodeRuneInString(tag)
+	return unicode.IsLetter(r)
+}
+
+// 解析以空格或逗号分隔的标签列表，# 前缀可有可无
+func parseTagList(input string) []string {
+	var tags []string
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	for _, field := range fields {
+		tags = appendTag(tags, strings.TrimPrefix(field, "#"))
+	}
+	return tags
+}
+
+func appendTag(tags []string, tag string) []string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || hasTag(tags, tag) {
+		return tags
+	}
+	return append(tags, tag)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// 两组标签是否相同，不考虑顺序（SQLite 按名称排序返回，界面中保持输入顺序）
+func sameTags(a, b []string) bool {
+	return len(a) == len(b) && hasAllTags(a, b)
+}
+
+// 任务是否包含过滤条件中的全部标签，过滤条件为空时总是匹配
+func hasAllTags(tags, filter []string) bool {
+	for _, tag := range filter {
+		if !hasTag(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// 以 "#a #b" 的形式展示标签
+func formatTags(tags []string) string {
+	parts := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		parts = append(parts, "#"+tag)
+	}
+	return strings.Join(parts, " ")
+}
+
+// 标题和标签拼回一行，用于编辑时回填输入框；不能写在标题中的标签不回填
+func formatTitleWithTags(title string, tags []string) string {
+	var inline []string
+	for _, tag := range tags {
+		if isTitleTag(tag) {
+			inline = append(inline, tag)
+		}
+	}
+	if len(inline) == 0 {
+		return title
+	}
+	return title + " " + formatTags(inline)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ====================== todo.txt 格式 ======================
+
+// 一行一个任务，例如
+//
+//	(A) 2026-10-17 写周报 +work @report due:2026-10-20 id:1a2b3c
+//	x 2026-10-18 2026-10-17 交电费 due:2026-10-18 pri:B id:4d5e6f
+//
+// 优先级 P0/P1/P2 对应 (A)/(B)/(C)，已完成的任务以 x 开头，后面是完成日期和创建日期，
+// 优先级按惯例写成 pri:。项目写成 +名称（空格换成 _），标签写成 @标签，
+// id: 和 parent: 用来保留 id 和父子关系。标题中会被当作项目、标签或上述 key: 的词
+// 前面加 \ 写入，读取时去掉，例如标题「ping @alice」写成 ping \@alice
+const todoTxtDateLayout = "2006-01-02"
+
+var (
+	todoTxtPriorityPattern = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtDatePattern     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// 有特殊含义的 key:value
+var todoTxtKeys = map[string]bool{"due": true, "pri": true, "id": true, "parent": true}
+
+// 优先级与字母的对应关系，D 及之后的字母都视为 P2
+var todoTxtPriorities = map[Priority]string{
+	PriorityHigh:   "A",
+	PriorityMedium: "B",
+	PriorityLow:    "C",
+}
+
+func parseTodoTxtPriority(letter string) Priority {
+	switch letter {
+	case "A":
+		return PriorityHigh
+	case "B":
+		return PriorityMedium
+	}
+	return PriorityLow
+}
+
+// todo.txt 中的项目名称不能包含空格
+func todoTxtProjectName(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// 按 todo.txt 中的写法查找项目，找不到时返回 -1
+func findTodoTxtProject(projects []Project, name string) int {
+	if index := findProjectByName(projects, name); index >= 0 {
+		return index
+	}
+	for i, project := range projects {
+		if strings.EqualFold(todoTxtProjectName(project.Name), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// 把任务写成一行，project 为项目名称，收件箱为空
+func formatTodoTxtLine(item TodoItem, project string) string {
+	var parts []string
+	if item.Done {
+		parts = append(parts, "x")
+		// 没有单独记录完成时间，用最后修改时间代替
+		if completed := cmp.Or(item.UpdatedAt, item.CreatedAt); !completed.IsZero() {
+			parts = append(parts, completed.Local().Format(todoTxtDateLayout))
+		}
+	} else {
+		parts = append(parts, "("+todoTxtPriorities[item.Priority]+")")
+	}
+	if !item.CreatedAt.IsZero() {
+		parts = append(parts, item.CreatedAt.Local().Format(todoTxtDateLayout))
+	}
+	for i, word := range strings.Fields(item.Title) {
+		// 标题开头的日期会被当作创建日期
+		if needsTodoTxtEscape(word) || (i == 0 && todoTxtDatePattern.MatchString(word)) {
+			word = `\` + word
+		}
+		parts = append(parts, word)
+	}
+	if project != "" {
+		parts = append(parts, "+"+todoTxtProjectName(project))
+	}
+	for _, tag := range item.Tags {
+		parts = append(parts, "@"+tag)
+	}
+	if item.HasDeadline {
+		parts = append(parts, "due:"+item.Deadline.Format(todoTxtDateLayout))
+	}
+	if item.Done {
+		parts = append(parts, "pri:"+todoTxtPriorities[item.Priority])
+	}
+	if item.id != "" {
+		parts = append(parts, "id:"+item.id)
+	}
+	if item.ParentID != "" {
+		parts = append(parts, "parent:"+item.ParentID)
+	}
+	return strings.Join(parts, " ")
+}
+
+// 标题中的词是否会被读成项目、标签或附加信息
+func needsTodoTxtEscape(word string) bool {
+	if strings.HasPrefix(word, `\`) {
+		return true
+	}
+	if len(word) > 1 && (word[0] == '+' || word[0] == '@') {
+		return true
+	}
+	if tag, ok := strings.CutPrefix(word, "#"); ok && isTitleTag(tag) {
+		return true
+	}
+	key, value, ok := strings.Cut(word, ":")
+	return ok && value != "" && todoTxtKeys[key]
+}
+
+// 解析一行，返回的任务中 project 为项目名称；没有 id: 时 id 为空。
+// 无法识别的 key:value、无法解析的 due: 和多余的 +项目 保留在标题中，以 \ 开头的词去掉 \ 后原样保留
+func parseTodoTxtLine(line string) (importedTask, error) {
+	fields := strings.Fields(line)
+	item := TodoItem{Priority: PriorityMedium}
+	var task importedTask
+
+	i := 0
+	if len(fields) > 0 && fields[0] == "x" {
+		item.Done = true
+		i++
+		// 完成日期在前，创建日期在后；完成日期作为修改时间
+		if i < len(fields) && todoTxtDatePattern.MatchString(fields[i]) {
+			completed, err := time.ParseInLocation(todoTxtDateLayout, fields[i], time.Local)
+			if err != nil {
+				return task, fmt.Errorf("无效的完成日期 %q", fields[i])
+			}
+			item.UpdatedAt = completed
+			i++
+		}
+	} else if len(fields) > 0 {
+		if m := todoTxtPriorityPattern.FindStringSubmatch(fields[0]); m != nil {
+			item.Priority = parseTodoTxtPriority(m[1])
+			i++
+		}
+	}
+	if i < len(fields) && todoTxtDatePattern.MatchString(fields[i]) {
+		created, err := time.ParseInLocation(todoTxtDateLayout, fields[i], time.Local)
+		if err != nil {
+			return task, fmt.Errorf("无效的创建日期 %q", fields[i])
+		}
+		item.CreatedAt = created
+		i++
+	}
+
+	var words []string
+	for _, word := range fields[i:] {
+		key, value, _ := strings.Cut(word, ":")
+		switch {
+		case strings.HasPrefix(word, `\`):
+			words = append(words, word[1:])
+		case len(word) > 1 && word[0] == '+' && task.project == "":
+			task.project = word[1:]
+		case len(word) > 1 && word[0] == '@':
+			item.Tags = appendTag(item.Tags, word[1:])
+		case key == "due" && value != "":
+			deadline, err := parseDue(value)
+			if err != nil {
+				words = append(words, word)
+				continue
+			}
+			item.HasDeadline = true
+			item.Deadline = deadline
+		case key == "pri" && len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z':
+			item.Priority = parseTodoTxtPriority(value)
+		case key == "id" && value != "":
+			item.id = value
+		case key == "parent" && value != "":
+			item.ParentID = value
+		default:
+			if tag, ok := strings.CutPrefix(word, "#"); ok && isTitleTag(tag) {
+				item.Tags = appendTag(item.Tags, tag)
+			} else {
+				words = append(words, word)
+			}
+		}
+	}
+
+	item.Title = strings.Join(words, " ")
+	if item.Title == "" {
+		return task, fmt.Errorf("缺少标题")
+	}
+	task.item = item
+	return task, nil
+}
+
+func writeTodoTxtExport(w io.Writer, items TodoList, projects []Project) error {
+	for _, item := range items {
+		project := ""
+		if item.ProjectID != inboxProjectID {
+			project = projectName(projects, item.ProjectID)
+		}
+		if _, err := fmt.Fprintln(w, formatTodoTxtLine(item, project)); err != nil {
+			return fmt.Errorf("导出 todo.txt 失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// 空行忽略
+func readTodoTxtImport(r io.Reader) ([]importedTask, error) {
+	var tasks []importedTask
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		task, err := parseTodoTxtLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 行：%v", lineNo, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 todo.txt 失败: %v", err)
+	}
+	return tasks, nil
+}
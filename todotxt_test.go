@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// 标题中像项目、标签、附加信息的词写入后应原样读回
+func TestTodoTxtTitleRoundTrip(t *testing.T) {
+	for _, title := range []string{
+		"check due:soon",
+		"call due:tomorrow",
+		"ping @alice about +release",
+		"fix #42 and #bug",
+		"id:x parent:y pri:A",
+		`path C:\tmp \n`,
+		"2026-10-17 复盘",
+		"x marks the spot",
+	} {
+		item := TodoItem{Title: title, Priority: PriorityHigh, Tags: []string{"work"}, id: "1a2b"}
+		line := formatTodoTxtLine(item, "Side Project")
+		task, err := parseTodoTxtLine(line)
+		if err != nil {
+			t.Errorf("%q 写成 %q 后无法读回: %v", title, line, err)
+			continue
+		}
+		got := task.item
+		if got.Title != title || !slices.Equal(got.Tags, item.Tags) || task.project != "Side_Project" ||
+			got.HasDeadline || got.id != item.id || got.Priority != item.Priority {
+			t.Errorf("%q 写成 %q 后读回 %+v，项目 %q", title, line, got, task.project)
+		}
+	}
+}
+
+// 无法解析的 due: 留在标题中，不影响整个文件
+func TestTodoTxtInvalidDue(t *testing.T) {
+	task, err := parseTodoTxtLine("(A) check due:soon @work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.item.Title != "check due:soon" || task.item.HasDeadline {
+		t.Errorf("读回 %+v", task.item)
+	}
+}
+
+func TestTodoTxtStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	store, err := NewTodoTxtStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{"check due:soon", "ping @alice about +release"}
+	for _, title := range titles {
+		if err := store.Create(TodoItem{Title: title, Priority: PriorityMedium, id: generateID()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewTodoTxtStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := reopened.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects, err := reopened.LoadProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("不应创建项目: %+v", projects)
+	}
+	var got []string
+	for _, item := range items {
+		if len(item.Tags) > 0 {
+			t.Errorf("「%s」不应有标签 %v", item.Title, item.Tags)
+		}
+		got = append(got, item.Title)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, titles) {
+		t.Errorf("读回的标题为 %q", got)
+	}
+}
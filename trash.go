@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ====================== 回收站 ======================
+
+// 是否位于回收站中
+func (ti TodoItem) Deleted() bool {
+	return !ti.DeletedAt.IsZero()
+}
+
+// 回收站列表按删除时间倒序，同时删除的保持树形顺序
+func (tl TodoList) sortTrash() {
+	tl.Sort()
+	order := make(map[string]int, len(tl))
+	for i, item := range tl {
+		order[item.id] = i
+	}
+	sort.SliceStable(tl, func(i, j int) bool {
+		if !tl[i].DeletedAt.Equal(tl[j].DeletedAt) {
+			return tl[i].DeletedAt.After(tl[j].DeletedAt)
+		}
+		return order[tl[i].id] < order[tl[j].id]
+	})
+}
+
+// 恢复任务时需要一并恢复的任务：自身、回收站中的子任务，以及回收站中的上级任务，
+// 保证恢复后的任务仍然挂在原来的父任务下。tl 需包含回收站中的任务
+func (tl TodoList) restoreIDs(id string) ([]string, error) {
+	byID := make(map[string]TodoItem, len(tl))
+	for _, item := range tl {
+		byID[item.id] = item
+	}
+	item, ok := byID[id]
+	if !ok || !item.Deleted() {
+		return nil, errNotInTrash(id)
+	}
+
+	ids := []string{id}
+	for _, childID := range tl.descendantIDs(id) {
+		if byID[childID].Deleted() {
+			ids = append(ids, childID)
+		}
+	}
+	for parentID := item.ParentID; parentID != ""; parentID = byID[parentID].ParentID {
+		parent, ok := byID[parentID]
+		if !ok || !parent.Deleted() {
+			break
+		}
+		ids = append(ids, parentID)
+	}
+	return ids, nil
+}
+
+// ====================== 回收站视图 ======================
+
+// 打开回收站视图
+func (m *Model) openTrash() {
+	if m.storage == nil {
+		m.statusLine = "当前没有可用的存储，回收站不可用"
+		return
+	}
+	m.mode = ModeTrash
+	m.trash.cursor = 0
+	m.statusLine = ""
+	m.reloadTrash()
+}
+
+func (m *Model) reloadTrash() {
+	items, err := m.storage.LoadTrash()
+	if err != nil {
+		m.statusLine = err.Error()
+		return
+	}
+	m.trash.items = items
+	if m.trash.cursor >= len(items) {
+		m.trash.cursor = len(items) - 1
+	}
+	if m.trash.cursor < 0 {
+		m.trash.cursor = 0
+	}
+}
+
+func (m *Model) handleTrashMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "T", "q":
+		return m.exitToNormalMode(), nil
+	case "up", "k":
+		if m.trash.cursor > 0 {
+			m.trash.cursor--
+		}
+	case "down", "j":
+		if m.trash.cursor < len(m.trash.items)-1 {
+			m.trash.cursor++
+		}
+	case "r", "enter":
+		m.restoreFromTrash()
+	case "x":
+		m.purgeFromTrash()
+	case "X":
+		m.emptyTrash()
+	}
+	return m, nil
+}
+
+func (m *Model) currentTrashItem() (TodoItem, bool) {
+	if m.trash.cursor < 0 || m.trash.cursor >= len(m.trash.items) {
+		return TodoItem{}, false
+	}
+	return m.trash.items[m.trash.cursor], true
+}
+
+// 恢复选中的任务，恢复操作可以撤销
+func (m *Model) restoreFromTrash() {
+	item, ok := m.currentTrashItem()
+	if !ok {
+		return
+	}
+	ids, err := m.trash.items.restoreIDs(item.id)
+	if err != nil {
+		// 上级任务不在回收站列表中时只按自身恢复，存储会补全
+		ids = []string{item.id}
+	}
+	if err := m.storage.Restore(item.id); err != nil {
+		m.statusLine = err.Error()
+		return
+	}
+
+	restored := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		restored[id] = true
+	}
+	for _, trashed := range m.trash.items {
+		if !restored[trashed.id] {
+			continue
+		}
+		trashed.DeletedAt = time.Time{}
+		trashed.Revision++
+		m.history.record(nil, &trashed)
+		m.persisted[trashed.id] = trashed.clone()
+		m.items = append(m.items, trashed)
+	}
+	m.sortItems()
+	m.reloadTrash()
+	m.statusLine = fmt.Sprintf("已恢复「%s」", item.Title)
+	if len(ids) > 1 {
+		m.statusLine += fmt.Sprintf("等 %d 项", len(ids))
+	}
+	m.selectedID = item.id
+}
+
+// 彻底删除选中的任务，需要确认且无法撤销
+func (m *Model) purgeFromTrash() {
+	item, ok := m.currentTrashItem()
+	if !ok {
+		return
+	}
+	m.startConfirm(fmt.Sprintf("彻底删除「%s」？此操作无法撤销", item.Title), func() {
+		if err := m.storage.Purge(item.id); err != nil {
+			m.statusLine = err.Error()
+			return
+		}
+		m.reloadTrash()
+		m.statusLine = fmt.Sprintf("已彻底删除「%s」", item.Title)
+	}, nil)
+}
+
+// 清空回收站
+func (m *Model) emptyTrash() {
+	if len(m.trash.items) == 0 {
+		return
+	}
+	m.startConfirm(fmt.Sprintf("清空回收站中的 %d 个任务？此操作无法撤销", len(m.trash.items)), func() {
+		count, err := m.storage.PurgeTrash(time.Now().Add(time.Second))
+		if err != nil {
+			m.statusLine = err.Error()
+			return
+		}
+		m.reloadTrash()
+		m.statusLine = fmt.Sprintf("已清空回收站，共 %d 个任务", count)
+	}, nil)
+}
+
+// 是否正在显示回收站（包括回收站中的确认提示）
+func (m *Model) inTrash() bool {
+	return m.mode == ModeTrash || (m.mode == ModeConfirm && m.confirm.returnMode == ModeTrash)
+}
+
+func (m *Model) renderTrashHeader() string {
+	title := m.styles.Header.
+		Background(lipgloss.Color("160")).
+		Foreground(lipgloss.Color("255")).
+		Render(" 回收站 ")
+	return " " + title + m.styles.Deadline.Render(fmt.Sprintf(" %d 个任务", len(m.trash.items)))
+}
+
+func (m *Model) renderTrash() string {
+	if len(m.trash.items) == 0 {
+		return "  " + m.styles.Help.Render("回收站是空的") + "\n"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("    " + m.styles.TableHeader.Width(21).Render("删除时间") +
+		m.styles.TableHeader.Render("任务") + "\n")
+
+	start, end := 0, len(m.trash.items)
+	if rows := m.trashCapacity(); end > rows {
+		// 与任务列表一样只显示光标附近的一段
+		start = m.trash.cursor - rows/2
+		if start < 0 {
+			start = 0
+		}
+		if start+rows > end {
+			start = end - rows
+		}
+		end = start + rows
+	}
+
+	for i := start; i < end; i++ {
+		item := m.trash.items[i]
+		line := item.DeletedAt.Format("2006-01-02 15:04") + "     " + truncateText(item.Title, 50)
+		if item.ParentID != "" {
+			line += m.styles.Deadline.Render("  (子任务)")
+		}
+		if i == m.trash.cursor {
+			builder.WriteString("  " + m.styles.Cursor.Render("▶ ") + m.styles.Selected.Render(line) + "\n")
+		} else {
+			builder.WriteString("    " + line + "\n")
+		}
+	}
+	if start > 0 || end < len(m.trash.items) {
+		builder.WriteString("  " + m.renderScrollIndicator(start, end, len(m.trash.items)) + "\n")
+	}
+	return builder.String()
+}
+
+// 回收站列表能显示的行数
+func (m *Model) trashCapacity() int {
+	if m.terminalHeight <= 0 {
+		return len(m.trash.items)
+	}
+	capacity := m.terminalHeight - 10
+	if capacity < 3 {
+		capacity = 3
+	}
+	return capacity
+}
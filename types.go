@@ -1,7 +1,8 @@
 package main
 
 import (
-	"sort"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,6 +31,20 @@ func (p Priority) String() string {
 	}
 }
 
+// 解析 P0/P1/P2 形式的优先级
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "P0":
+		return PriorityHigh, nil
+	case "P1":
+		return PriorityMedium, nil
+	case "P2":
+		return PriorityLow, nil
+	default:
+		return PriorityMedium, fmt.Errorf("无效的优先级 %q，可选 P0/P1/P2", s)
+	}
+}
+
 type Mode int
 
 const (
@@ -37,6 +52,13 @@ const (
 	ModeInputTitle
 	ModePickDate
 	ModePickPriority
+	ModeConfirm
+	ModePickRecurrence
+	ModeSearch
+	ModeTrash
+	ModeEditNotes
+	ModeImport
+	ModeConflict
 )
 
 type InputContext int
@@ -47,6 +69,14 @@ const (
 	InputContextEditTitle
 	InputContextAddPriority
 	InputContextEditPriority
+	InputContextFilterTags
+	InputContextAddProject
+	InputContextMoveProject
+	InputContextBulkPriority
+	InputContextShiftDays
+	InputContextBulkTag
+	InputContextExport
+	InputContextImport
 )
 
 type DateField int
@@ -64,13 +94,39 @@ const (
 
 type TodoItem struct {
 	Title       string
+	Notes       string // 多行备注，Markdown 格式
 	Done        bool
 	Priority    Priority
 	HasDeadline bool
 	Deadline    time.Time
+	Tags        []string
+	ProjectID   string
+	ParentID    string // 父任务ID，为空表示顶层任务
+	Recurrence  Recurrence
+	Position    int // 手动排序中的位置，0 表示未调整过，排在同优先级任务的最后
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	DeletedAt   time.Time // 移入回收站的时间，零值表示未删除
+	Revision    int       // 修订号，每次写入存储后加一，用于发现其他地方的修改
 	id          string
 }
 
+// 深拷贝，避免存储与界面共用切片
+func (ti TodoItem) clone() TodoItem {
+	ti.Tags = append([]string(nil), ti.Tags...)
+	ti.Recurrence.Weekdays = append([]time.Weekday(nil), ti.Recurrence.Weekdays...)
+	return ti
+}
+
+// 记录修改时间，新任务同时记录创建时间
+func (ti *TodoItem) touch() {
+	now := time.Now()
+	if ti.CreatedAt.IsZero() {
+		ti.CreatedAt = now
+	}
+	ti.UpdatedAt = now
+}
+
 // 用于生成唯一ID
 func generateID() string {
 	return uuid.New().String()
@@ -89,27 +145,7 @@ func (ti *TodoItem) DeadlineString() string {
 
 type TodoList []TodoItem
 
+// 按默认方式（优先级）排序后按父子关系排列
 func (tl TodoList) Sort() {
-	sort.SliceStable(tl, func(i, j int) bool {
-		a, b := tl[i], tl[j]
-
-		// 1. 未完成的在前
-		if a.Done != b.Done {
-			return !a.Done && b.Done
-		}
-		// 2. 优先级高的在前
-		if a.Priority != b.Priority {
-			return a.Priority > b.Priority
-		}
-		// 3. 有截止日期的在前
-		if a.HasDeadline != b.HasDeadline {
-			return a.HasDeadline && !b.HasDeadline
-		}
-		// 4. 截止日期早的在前
-		if a.HasDeadline && b.HasDeadline {
-			return a.Deadline.Before(b.Deadline)
-		}
-		// 5. 按标题字母排序
-		return a.Title < b.Title
-	})
+	tl.SortBy(SortByPriority)
 }
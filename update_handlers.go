@@ -1,20 +1,42 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kakkk/todo_cli/dateparse"
 )
 
 // ====================== 更新逻辑 ======================
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	// 写入时发现的冲突在按键处理完后统一提示
+	m.promptConflicts()
+	// 一次按键产生的修改作为一步记入撤销历史
+	if err := m.history.commit(); err != nil {
+		m.statusLine = err.Error()
+	}
+	// 任何变化之后都让光标保持在可视区域内
+	m.syncViewport()
+	return model, cmd
+}
+
+func (m *Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
+	case editorFinishedMsg:
+		m.finishExternalEdit(msg)
+		return m, nil
+	case reloadTickMsg:
+		m.checkExternalChanges()
+		return m, watchStore()
 	case tea.WindowSizeMsg:
 		m.terminalWidth = msg.Width
+		m.terminalHeight = msg.Height
 		// 更新输入框宽度，确保能够显示完整内容
 		width := msg.Width - 4 // 减去边距
 		if width > 60 {
@@ -24,6 +46,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			width = 40 // 保证最小宽度
 		}
 		m.input.Width = width
+		// 备注编辑器与表格同宽，终端较窄时跟随终端
+		m.notes.SetWidth(min(detailWidth-2, msg.Width-4))
 		return m, nil
 	}
 	return m, nil
@@ -39,6 +63,20 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handlePriorityPicker(msg)
 	case ModePickDate:
 		return m.handleDatePicker(msg)
+	case ModeConfirm:
+		return m.handleConfirm(msg)
+	case ModePickRecurrence:
+		return m.handleRecurrencePicker(msg)
+	case ModeSearch:
+		return m.handleSearchMode(msg)
+	case ModeTrash:
+		return m.handleTrashMode(msg)
+	case ModeEditNotes:
+		return m.handleNotesMode(msg)
+	case ModeImport:
+		return m.handleImportMode(msg)
+	case ModeConflict:
+		return m.handleConflictMode(msg)
 	}
 	return m, nil
 }
@@ -53,28 +91,110 @@ func (m *Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case "up", "k":
 		m.moveCursor(-1)
-		// 更新选中的ID
-		if m.cursor >= 0 && m.cursor < len(m.items) {
-			m.selectedID = m.items[m.cursor].id
-		}
 	case "down", "j":
 		m.moveCursor(1)
-		// 更新选中的ID
-		if m.cursor >= 0 && m.cursor < len(m.items) {
-			m.selectedID = m.items[m.cursor].id
-		}
+	case "pgup", "ctrl+b":
+		m.scrollPage(-1)
+	case "pgdown", "ctrl+f":
+		m.scrollPage(1)
+	case "ctrl+u":
+		m.scrollPage(-0.5)
+	case "ctrl+d":
+		m.scrollPage(0.5)
+	case "g", "home":
+		m.jumpToTop()
+	case "G", "end":
+		m.jumpToBottom()
+	case "s":
+		m.cycleSortMode()
+	case "K", "ctrl+up", "shift+up":
+		m.moveItem(-1)
+	case "J", "ctrl+down", "shift+down":
+		m.moveItem(1)
+	case "T":
+		m.openTrash()
+	case "u":
+		m.undo()
+	case "ctrl+r":
+		m.redo()
 	case "a":
 		m.startAddingItem()
 		return m, m.input.Focus()
 	case "e":
 		m.startEditingItem()
 		return m, m.input.Focus()
+	case "n":
+		return m, m.startEditingNotes()
+	case "E":
+		return m, m.startExternalEdit()
+	case "o":
+		m.startExport()
+		return m, m.input.Focus()
+	case "I":
+		m.startImport()
+		return m, m.input.Focus()
+	case "i":
+		m.toggleDetail()
 	case " ":
-		m.toggleCompletion()
+		if m.hasMarks() {
+			m.bulkToggleDone()
+		} else {
+			m.toggleCompletion()
+		}
 		return m, nil
 	case "x":
-		m.deleteCurrentItem()
+		if m.hasMarks() {
+			m.bulkDelete()
+		} else {
+			m.deleteCurrentItem()
+		}
 		return m, nil
+	case "m":
+		m.toggleMark()
+	case "v":
+		m.toggleVisual()
+	case "p":
+		m.startBulkPriority()
+	case "+":
+		return m, m.startBulkInput(InputContextShiftDays, "顺延天数，例如 3 或 -1")
+	case "#":
+		return m, m.startBulkInput(InputContextBulkTag, "#标签，多个用空格分隔")
+	case "D":
+		if index := m.currentIndex(); index >= 0 {
+			m.clearDeadline(m.items[index].id)
+		}
+	case "t":
+		m.startTagFilter()
+		return m, m.input.Focus()
+	case "/":
+		return m, m.startSearch()
+	case "esc":
+		// 先清除标记，再清除搜索
+		if m.hasMarks() {
+			m.clearMarks()
+			m.statusLine = ""
+		} else {
+			m.clearSearch()
+		}
+	case "tab":
+		m.switchProject(1)
+	case "shift+tab":
+		m.switchProject(-1)
+	case "N":
+		m.startAddingProject()
+		return m, m.input.Focus()
+	case "M":
+		m.startMovingItem()
+		return m, m.input.Focus()
+	case "Z":
+		m.archiveCurrentProject()
+	case "A":
+		m.startAddingSubtask()
+		return m, m.input.Focus()
+	case "left", "h":
+		m.collapseCurrent()
+	case "right", "l":
+		m.expandCurrent()
 	}
 	return m, nil
 }
@@ -95,7 +215,7 @@ func (m *Model) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m *Model) handlePriorityPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
-		return m.exitToNormalMode(), nil
+		return m.cancelPicker(), nil
 	case "enter":
 		return m.confirmPrioritySelection(), nil
 	case "up", "k", "left", "h":
@@ -107,11 +227,19 @@ func (m *Model) handlePriorityPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) handleDatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.datePicker.typing {
+		return m.handleDateInput(msg)
+	}
+
 	switch msg.String() {
 	case "esc":
-		return m.exitToNormalMode(), nil
+		return m.cancelPicker(), nil
 	case "enter":
 		return m.confirmDateSelection(), nil
+	case "n":
+		return m.confirmNoDeadline(), nil
+	case "i", "/":
+		return m, m.startDateInput()
 	case "left", "h":
 		m.rotateDateField(-1)
 	case "right", "l":
@@ -124,19 +252,102 @@ func (m *Model) handleDatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m *Model) handleConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		onYes := m.confirm.onYes
+		m.endConfirm()
+		if onYes != nil {
+			onYes()
+		}
+	case "n", "N":
+		onNo := m.confirm.onNo
+		m.endConfirm()
+		if onNo != nil {
+			onNo()
+		}
+	case "esc", "ctrl+c":
+		return m.endConfirm(), nil
+	}
+	return m, nil
+}
+
+// 结束确认提示，回到进入确认前的模式
+func (m *Model) endConfirm() *Model {
+	m.exitToNormalMode()
+	m.mode = m.confirm.returnMode
+	return m
+}
+
 // ====================== 操作辅助方法 ======================
 
+// 进入确认提示，onNo 为空时 n 等同于取消
+func (m *Model) startConfirm(prompt string, onYes, onNo func()) {
+	m.confirm.returnMode = m.mode
+	m.mode = ModeConfirm
+	m.confirm.prompt = prompt
+	m.confirm.onYes = onYes
+	m.confirm.onNo = onNo
+	m.statusLine = ""
+}
+
+// 应用过滤条件后可见的任务，元素为 m.items 中的下标
+func (m *Model) visibleIndexes() []int {
+	indexes := make([]int, 0, len(m.items))
+	byID := m.indexMap()
+	for i := range m.items {
+		if m.isVisible(&m.items[i], byID) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// byID 为 indexMap 的结果，用于查找上级任务
+func (m *Model) isVisible(item *TodoItem, byID map[string]int) bool {
+	// 搜索时折叠起来的子任务也要能搜到
+	return m.matchesFilter(item) && (m.searchQuery != "" || !m.isCollapsedAway(item, byID))
+}
+
+// 是否符合项目、标签和搜索过滤条件（不考虑折叠）
+func (m *Model) matchesFilter(item *TodoItem) bool {
+	if m.currentProject != allProjectsID && item.ProjectID != m.currentProject {
+		return false
+	}
+	return hasAllTags(item.Tags, m.tagFilter) && fuzzyMatch(m.searchQuery, item.Title)
+}
+
+// 光标所在任务在 m.items 中的下标，没有可见任务时返回 -1
+func (m *Model) currentIndex() int {
+	visible := m.visibleIndexes()
+	if m.cursor < 0 || m.cursor >= len(visible) {
+		return -1
+	}
+	return visible[m.cursor]
+}
+
+// 移动光标，超出范围时停在第一行或最后一行
 func (m *Model) moveCursor(delta int) {
+	visible := m.visibleIndexes()
+	if len(visible) == 0 {
+		return
+	}
 	newPos := m.cursor + delta
-	if newPos >= 0 && newPos < len(m.items) {
-		m.cursor = newPos
+	if newPos < 0 {
+		newPos = 0
 	}
+	if newPos >= len(visible) {
+		newPos = len(visible) - 1
+	}
+	m.cursor = newPos
+	// 更新选中的ID
+	m.selectedID = m.items[visible[newPos]].id
 }
 
 func (m *Model) startAddingItem() {
 	m.mode = ModeInputTitle
 	m.inputContext = InputContextAddTitle
-	m.draftItem = TodoItem{Priority: PriorityMedium, id: generateID()}
+	m.draftItem = TodoItem{Priority: PriorityMedium, ProjectID: m.targetProjectID(), id: generateID()}
 	m.input.SetValue("")
 	m.input.Placeholder = "新任务内容"
 	m.input.Focus()
@@ -145,12 +356,14 @@ func (m *Model) startAddingItem() {
 }
 
 func (m *Model) startEditingItem() {
-	if len(m.items) == 0 {
+	index := m.currentIndex()
+	if index < 0 {
 		return
 	}
 	m.mode = ModeInputTitle
 	m.inputContext = InputContextEditTitle
-	m.input.SetValue(m.items[m.cursor].Title)
+	m.editingID = m.items[index].id
+	m.input.SetValue(formatTitleWithTags(m.items[index].Title, m.items[index].Tags))
 	m.input.Placeholder = "编辑内容"
 	m.input.Focus()
 	m.input.CursorEnd()
@@ -159,18 +372,33 @@ func (m *Model) startEditingItem() {
 
 // 修改完成任务状态的方法
 func (m *Model) toggleCompletion() {
-	if len(m.items) == 0 {
+	index := m.currentIndex()
+	if index < 0 {
 		return
 	}
 
 	// 记录当前选中任务的ID
-	currentID := m.items[m.cursor].id
-
-	// 切换完成状态
-	m.items[m.cursor].Done = !m.items[m.cursor].Done
+	currentID := m.items[index].id
+	done := !m.items[index].Done
+
+	// 完成父任务时询问是否一并完成子任务
+	if pending := m.pendingDescendants(currentID); done && len(pending) > 0 {
+		m.startConfirm(
+			fmt.Sprintf("还有 %d 个未完成的子任务，是否一并完成？", len(pending)),
+			func() {
+				m.setDone(append([]string{currentID}, pending...), true)
+				m.findItemByID(currentID)
+			},
+			func() {
+				m.setDone([]string{currentID}, true)
+				m.findItemByID(currentID)
+			},
+		)
+		return
+	}
 
-	// 保存更改（这会触发排序）
-	m.saveChanges()
+	// 切换完成状态（保存时会触发排序）
+	m.setDone([]string{currentID}, done)
 
 	// 根据ID重新定位光标
 	m.findItemByID(currentID)
@@ -178,56 +406,98 @@ func (m *Model) toggleCompletion() {
 
 // 删除当前项目的方法
 func (m *Model) deleteCurrentItem() {
-	if len(m.items) == 0 {
+	index := m.currentIndex()
+	if index < 0 {
 		return
 	}
 
-	// 删除当前项目
-	m.items = append(m.items[:m.cursor], m.items[m.cursor+1:]...)
+	// 删除当前项目，移入回收站
+	title := m.items[index].Title
+	m.removeItem(m.items[index].id)
+	if m.statusLine == "" {
+		m.statusLine = fmt.Sprintf("「%s」已移入回收站，u 撤销，T 查看回收站", title)
+	}
 
 	// 如果删除后还有项目，调整光标位置
-	if len(m.items) > 0 {
+	visible := m.visibleIndexes()
+	if len(visible) > 0 {
 		// 如果删除的是最后一个项目，将光标移到新的最后一个项目
-		if m.cursor >= len(m.items) {
-			m.cursor = len(m.items) - 1
+		if m.cursor >= len(visible) {
+			m.cursor = len(visible) - 1
 		}
 		// 更新选中的ID为当前光标位置的项目ID
-		if m.cursor >= 0 && m.cursor < len(m.items) {
-			m.selectedID = m.items[m.cursor].id
-		}
+		m.selectedID = m.items[visible[m.cursor]].id
 	} else {
 		// 没有项目了，重置光标和选中ID
 		m.cursor = 0
 		m.selectedID = ""
 	}
+}
 
-	m.saveChanges()
+// 任务 id 到 m.items 下标的映射，需要反复按 id 查找时先建好；没有折叠的任务时不需要，返回 nil
+func (m *Model) indexMap() map[string]int {
+	if len(m.collapsed) == 0 {
+		return nil
+	}
+	byID := make(map[string]int, len(m.items))
+	for i, item := range m.items {
+		byID[item.id] = i
+	}
+	return byID
 }
 
-// 根据ID查找项目并设置光标位置
-func (m *Model) findItemByID(id string) {
+// 在完整列表中查找任务下标，找不到时返回 -1
+func (m *Model) indexByID(id string) int {
 	for i, item := range m.items {
 		if item.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// 根据ID查找项目并设置光标位置，光标位置以可见列表为准
+func (m *Model) findItemByID(id string) {
+	visible := m.visibleIndexes()
+	for i, index := range visible {
+		if m.items[index].id == id {
 			m.cursor = i
 			m.selectedID = id
 			return
 		}
 	}
 	// 如果没找到，尝试选中第一个未完成的项目
-	for i, item := range m.items {
-		if !item.Done {
+	for i, index := range visible {
+		if !m.items[index].Done {
 			m.cursor = i
-			m.selectedID = item.id
+			m.selectedID = m.items[index].id
 			return
 		}
 	}
 	// 如果所有项目都已完成，选中第一个
-	if len(m.items) > 0 {
-		m.cursor = 0
-		m.selectedID = m.items[0].id
+	m.cursor = 0
+	m.selectedID = ""
+	if len(visible) > 0 {
+		m.selectedID = m.items[visible[0]].id
 	}
 }
 
+func (m *Model) startTagFilter() {
+	m.mode = ModeInputTitle
+	m.inputContext = InputContextFilterTags
+	m.input.SetValue(formatTags(m.tagFilter))
+	m.input.Placeholder = "#标签，多个用空格分隔，留空清除过滤"
+	m.input.Focus()
+	m.input.CursorEnd()
+	m.statusLine = "输入标签后回车过滤，Esc 取消"
+}
+
+// 设置标签过滤条件，并尽量保持当前选中的任务
+func (m *Model) applyTagFilter(tags []string) {
+	m.tagFilter = tags
+	m.findItemByID(m.selectedID)
+}
+
 func (m *Model) cancelInput() (tea.Model, tea.Cmd) {
 	m.mode = ModeNormal
 	m.statusLine = ""
@@ -237,19 +507,67 @@ func (m *Model) cancelInput() (tea.Model, tea.Cmd) {
 
 func (m *Model) confirmInput() (tea.Model, tea.Cmd) {
 	value := strings.TrimSpace(m.input.Value())
-	if value == "" {
+	switch m.inputContext {
+	case InputContextFilterTags:
+		// 过滤条件允许为空，为空时清除过滤
+		m.applyTagFilter(parseTagList(value))
+		return m.exitToNormalMode(), nil
+	case InputContextAddProject:
+		m.confirmAddProject(value)
+		return m.exitToNormalModeKeepStatus(), nil
+	case InputContextMoveProject:
+		m.confirmMoveItem(value)
+		return m.exitToNormalModeKeepStatus(), nil
+	case InputContextShiftDays:
+		if !m.confirmShiftDeadline(value) {
+			return m, nil
+		}
+		return m.exitToNormalModeKeepStatus(), nil
+	case InputContextBulkTag:
+		if !m.confirmBulkTag(value) {
+			return m, nil
+		}
+		return m.exitToNormalModeKeepStatus(), nil
+	case InputContextExport:
+		if !m.confirmExport(value) {
+			return m, nil
+		}
+		return m.exitToNormalModeKeepStatus(), nil
+	case InputContextImport:
+		if !m.confirmImportPath(value) {
+			return m, nil
+		}
+		return m, nil
+	}
+
+	title, tags := parseTitleTags(value)
+	if title == "" {
 		m.statusLine = "内容不能为空"
 		return m, nil
 	}
 
 	switch m.inputContext {
 	case InputContextAddTitle:
-		m.draftItem.Title = value
+		m.draftItem.Title = title
+		m.draftItem.Tags = tags
 		m.draftItem.id = generateID() // 确保有ID
 		m.startPriorityPicker(InputContextAddPriority, PriorityMedium)
 	case InputContextEditTitle:
-		m.items[m.cursor].Title = value
-		m.startPriorityPicker(InputContextEditPriority, m.items[m.cursor].Priority)
+		index := m.indexByID(m.editingID)
+		if index < 0 {
+			return m.exitToNormalMode(), nil
+		}
+		// 输入框中没有回填的标签（如 #42）保留下来
+		for _, tag := range m.items[index].Tags {
+			if !isTitleTag(tag) {
+				tags = appendTag(tags, tag)
+			}
+		}
+		m.items[index].Title = title
+		m.items[index].Tags = tags
+		m.updateItem(m.items[index])
+		m.findItemByID(m.editingID)
+		m.startPriorityPicker(InputContextEditPriority, m.items[m.indexByID(m.editingID)].Priority)
 	}
 
 	m.input.Blur()
@@ -260,7 +578,7 @@ func (m *Model) startPriorityPicker(context InputContext, initial Priority) {
 	m.mode = ModePickPriority
 	m.inputContext = context
 	m.priorityPicker.priority = initial
-	m.statusLine = "↑/↓ 选择优先级 • Enter 确认 • Esc 取消"
+	m.statusLine = "↑/↓ 选择优先级 • Enter 确认 • Esc 跳过"
 }
 
 func (m *Model) rotatePriority(delta int) {
@@ -270,22 +588,28 @@ func (m *Model) rotatePriority(delta int) {
 }
 
 func (m *Model) confirmPrioritySelection() tea.Model {
-	if m.inputContext == InputContextAddPriority {
+	switch m.inputContext {
+	case InputContextAddPriority:
 		m.draftItem.Priority = m.priorityPicker.priority
 		m.startDatePicker(-1)
-	} else {
-		// 记录当前选中任务的ID
-		currentID := m.items[m.cursor].id
+	case InputContextBulkPriority:
+		m.exitToNormalMode()
+		m.confirmBulkPriority(m.priorityPicker.priority)
+	default:
+		index := m.indexByID(m.editingID)
+		if index < 0 {
+			return m.exitToNormalMode()
+		}
 
-		m.items[m.cursor].Priority = m.priorityPicker.priority
+		m.items[index].Priority = m.priorityPicker.priority
 
 		// 保存更改并排序
-		m.saveChanges()
+		m.updateItem(m.items[index])
 
 		// 根据ID重新定位光标
-		m.findItemByID(currentID)
+		m.findItemByID(m.editingID)
 
-		m.startDatePicker(m.cursor)
+		m.startDatePicker(m.indexByID(m.editingID))
 	}
 	return m
 }
@@ -301,7 +625,43 @@ func (m *Model) startDatePicker(index int) {
 			17, 0, 0, 0, now.Location())
 	}
 	m.datePicker.field = DateFieldHour
-	m.statusLine = "←/→ 切换字段 • ↑/↓ 调整时间 • Enter 确认 • Esc 取消"
+	m.datePicker.typing = false
+	m.statusLine = datePickerHint
+}
+
+const datePickerHint = "←/→ 切换字段 • ↑/↓ 调整时间 • i 输入日期 • Enter 确认 • n 无截止日期 • Esc 跳过"
+
+// 切换到文字输入日期，例如 "明天下午3点"、"fri 9am"
+func (m *Model) startDateInput() tea.Cmd {
+	m.datePicker.typing = true
+	m.input.SetValue("")
+	m.input.Placeholder = "例如 明天下午3点、下周五、fri 9am、in 3 days、2026-11-01 14:30"
+	m.statusLine = "Enter 确认 • Esc 返回逐项调整"
+	return m.input.Focus()
+}
+
+func (m *Model) handleDateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.datePicker.typing = false
+		m.input.Blur()
+		m.statusLine = datePickerHint
+		return m, nil
+	case "enter":
+		date, err := dateparse.Parse(m.input.Value(), time.Now())
+		if err != nil {
+			m.statusLine = err.Error()
+			return m, nil
+		}
+		m.datePicker.date = date
+		m.datePicker.typing = false
+		m.input.Blur()
+		return m.confirmDateSelection(), nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
 }
 
 func (m *Model) rotateDateField(delta int) {
@@ -327,52 +687,187 @@ func (m *Model) adjustDate(delta int) {
 	}
 }
 
+// 确认截止日期后进入重复规则选择
 func (m *Model) confirmDateSelection() tea.Model {
 	if m.inputContext == InputContextAddPriority {
 		m.draftItem.HasDeadline = true
 		m.draftItem.Deadline = m.datePicker.date
-		m.items = append(m.items, m.draftItem)
-		m.draftItem = TodoItem{}
-		// 保存更改并排序
-		m.saveChanges()
-		// 选中新添加的项目（应该在最后）
-		if len(m.items) > 0 {
-			m.cursor = len(m.items) - 1
-			m.selectedID = m.items[m.cursor].id
-		}
-	} else {
-		// 记录当前选中任务的ID
-		currentID := m.items[m.datePicker.index].id
+		m.startRecurrencePicker(m.draftItem.Recurrence, m.draftItem.Deadline)
+		return m
+	}
 
-		m.items[m.datePicker.index].HasDeadline = true
-		m.items[m.datePicker.index].Deadline = m.datePicker.date
+	// 记录当前选中任务的ID
+	currentID := m.items[m.datePicker.index].id
 
-		// 保存更改并排序
-		m.saveChanges()
+	m.items[m.datePicker.index].HasDeadline = true
+	m.items[m.datePicker.index].Deadline = m.datePicker.date
 
-		// 根据ID重新定位光标
-		m.findItemByID(currentID)
+	// 保存更改并排序
+	m.updateItem(m.items[m.datePicker.index])
+
+	// 根据ID重新定位光标
+	m.findItemByID(currentID)
+
+	item := m.items[m.indexByID(currentID)]
+	m.startRecurrencePicker(item.Recurrence, item.Deadline)
+	return m
+}
+
+// 不设置截止日期：新任务直接保存，已有任务清除截止日期
+func (m *Model) confirmNoDeadline() tea.Model {
+	if m.inputContext == InputContextAddPriority {
+		m.draftItem.HasDeadline = false
+		m.draftItem.Deadline = time.Time{}
+		m.draftItem.Recurrence = Recurrence{}
+		m.saveDraft()
+		return m.exitToNormalModeKeepStatus()
+	}
+
+	m.clearDeadline(m.items[m.datePicker.index].id)
+	return m.exitToNormalModeKeepStatus()
+}
+
+// 清除任务的截止日期，重复规则依赖截止日期，一并清除
+func (m *Model) clearDeadline(id string) {
+	index := m.indexByID(id)
+	if index < 0 {
+		return
+	}
+	m.statusLine = ""
+	m.items[index].HasDeadline = false
+	m.items[index].Deadline = time.Time{}
+	m.items[index].Recurrence = Recurrence{}
+	m.updateItem(m.items[index])
+	m.findItemByID(id)
+	if m.statusLine == "" {
+		m.statusLine = "已清除截止日期"
 	}
+}
+
+// 在选择器中按 Esc：添加任务时保留已经填写的内容，编辑时之前的步骤已经保存
+func (m *Model) cancelPicker() *Model {
+	if m.inputContext == InputContextAddPriority && m.draftItem.Title != "" {
+		m.saveDraft()
+		return m.exitToNormalModeKeepStatus()
+	}
+	return m.exitToNormalMode()
+}
+
+// 保存正在添加的任务并选中它
+func (m *Model) saveDraft() {
+	m.statusLine = ""
+	newID := m.draftItem.id
+	// 保存更改并排序
+	m.createItem(m.draftItem)
+	m.draftItem = TodoItem{}
+	// 选中新添加的项目
+	m.findItemByID(newID)
+}
 
+func (m *Model) exitToNormalMode() *Model {
 	m.mode = ModeNormal
 	m.statusLine = ""
 	m.input.Blur()
 	return m
 }
 
-func (m *Model) exitToNormalMode() *Model {
+// 返回普通模式但保留状态栏中的提示
+func (m *Model) exitToNormalModeKeepStatus() *Model {
 	m.mode = ModeNormal
-	m.statusLine = ""
 	m.input.Blur()
 	return m
 }
 
-func (m *Model) saveChanges() {
+// ====================== 持久化 ======================
+
+// 新增任务：写入存储后加入列表并排序
+func (m *Model) createItem(item TodoItem) {
+	item.touch()
 	if m.storage != nil {
-		if msg := m.storage.Save(m.items); msg != "" {
-			m.statusLine = msg
-		} else {
-			m.statusLine = ""
+		if err := m.storage.Create(item); err != nil {
+			m.statusLine = err.Error()
+			return
 		}
 	}
+	item.Revision++
+	m.history.record(nil, &item)
+	m.persisted[item.id] = item.clone()
+	m.items = append(m.items, item)
+	m.sortItems()
+	// 新建子任务后展开父任务，确保能看到
+	delete(m.collapsed, item.ParentID)
+	m.statusLine = ""
+}
+
+// 更新单个任务并重新排序，只写入这一条记录。
+// 任务已被其他地方修改或删除时不写入，按键处理完后提示用户如何处理
+func (m *Model) updateItem(item TodoItem) {
+	m.writeItem(item, false)
+}
+
+// restore 为 true 时用 Upsert 写入：处理冲突时保留我的修改，任务已在回收站中时一并恢复
+func (m *Model) writeItem(item TodoItem, restore bool) {
+	m.statusLine = ""
+	item.touch()
+	if m.storage != nil {
+		write := m.storage.Update
+		if restore {
+			write = m.storage.Upsert
+		}
+		if err := write(item); err != nil {
+			retry := func(resolved *TodoItem) {
+				if resolved == nil {
+					return
+				}
+				if index := m.indexByID(resolved.id); index >= 0 {
+					m.items[index] = resolved.clone()
+				}
+				m.writeItem(*resolved, true)
+			}
+			// 调用方据状态栏判断是否写入成功，冲突时同样设置
+			m.statusLine = err.Error()
+			m.queueConflict(err, item, retry)
+			m.sortItems()
+			return
+		}
+	}
+	item.Revision++
+	if index := m.indexByID(item.id); index >= 0 {
+		m.items[index].UpdatedAt = item.UpdatedAt
+		m.items[index].Revision = item.Revision
+	}
+	if before, ok := m.persisted[item.id]; ok {
+		m.history.record(&before, &item)
+	}
+	m.persisted[item.id] = item.clone()
+	m.sortItems()
+}
+
+// 从存储和列表中删除任务
+func (m *Model) removeItem(id string) {
+	if m.storage != nil {
+		if err := m.storage.Delete(id); err != nil {
+			m.statusLine = err.Error()
+			return
+		}
+	}
+	// 存储会级联删除子任务，列表中同样移除
+	removed := map[string]bool{id: true}
+	for _, childID := range m.items.descendantIDs(id) {
+		removed[childID] = true
+	}
+	for _, item := range m.items {
+		if before, ok := m.persisted[item.id]; ok && removed[item.id] {
+			m.history.record(&before, nil)
+			delete(m.persisted, item.id)
+		}
+	}
+	kept := m.items[:0]
+	for _, item := range m.items {
+		if !removed[item.id] {
+			kept = append(kept, item)
+		}
+	}
+	m.items = kept
+	m.statusLine = ""
 }
@@ -3,8 +3,10 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/kakkk/todo_cli/dateparse"
 )
 
 // ====================== 视图渲染 ======================
@@ -12,16 +14,48 @@ import (
 func (m *Model) View() string {
 	var builder strings.Builder
 
+	// 项目切换栏，只有建过项目时才显示
+	builder.WriteString("\n")
+	if len(m.projects) > 0 {
+		builder.WriteString(m.renderProjectTabs() + "\n\n")
+	}
+
+	// 回收站视图替换任务列表
+	if m.inTrash() {
+		builder.WriteString(m.renderTrashHeader() + "\n\n")
+		builder.WriteString(m.renderTrash())
+		builder.WriteString(m.renderInteractiveArea())
+		if m.statusLine != "" {
+			builder.WriteString("\n  " + m.styles.Status.Render("● ") + m.statusLine)
+		}
+		return builder.String()
+	}
+
+	// 导入预览替换任务列表
+	if m.mode == ModeImport {
+		builder.WriteString(m.renderImportPreview())
+		builder.WriteString(m.renderInteractiveArea())
+		if m.statusLine != "" {
+			builder.WriteString("\n  " + m.styles.Status.Render("● ") + m.statusLine)
+		}
+		return builder.String()
+	}
+
 	// 标题栏
-	builder.WriteString("\n" + m.renderHeader() + "\n\n")
+	builder.WriteString(m.renderHeader() + "\n\n")
 
 	// 任务列表
-	if len(m.items) == 0 {
+	if len(m.visibleIndexes()) == 0 {
 		builder.WriteString(m.renderEmptyState())
 	} else {
 		builder.WriteString(m.renderTodoTable())
 	}
 
+	// 详情面板
+	if detail := m.renderDetail(); detail != "" {
+		builder.WriteString("\n" + detail)
+	}
+
 	// 交互区域
 	builder.WriteString(m.renderInteractiveArea())
 
@@ -34,12 +68,14 @@ func (m *Model) View() string {
 }
 
 func (m *Model) renderHeader() string {
-	doneCount := 0
-	for _, item := range m.items {
-		if item.Done {
-			doneCount++
+	// 进度只统计叶子任务，折叠不影响统计
+	var matched []TodoItem
+	for i := range m.items {
+		if m.matchesFilter(&m.items[i]) {
+			matched = append(matched, m.items[i])
 		}
 	}
+	doneCount, totalCount := leafProgress(matched, m.items.childCounts())
 
 	title := m.styles.Header.
 		Background(lipgloss.Color("62")).
@@ -47,13 +83,70 @@ func (m *Model) renderHeader() string {
 		Render(" TODO ")
 
 	stats := m.styles.Deadline.Render(
-		fmt.Sprintf(" %d/%d 已完成", doneCount, len(m.items)),
+		fmt.Sprintf(" %d/%d 已完成", doneCount, totalCount),
 	)
 
+	stats += "  " + m.styles.Deadline.Render("排序: "+m.sortMode.Label())
+
+	// 标签过滤提示
+	if len(m.tagFilter) > 0 {
+		stats += "  " + m.styles.Tag.Render("过滤: "+formatTags(m.tagFilter))
+	}
+	// 搜索提示
+	if m.searchQuery != "" {
+		stats += "  " + m.styles.Selected.Render("搜索: "+m.searchQuery)
+	}
+	// 多选提示
+	if m.hasMarks() {
+		stats += "  " + m.styles.Cursor.Render(fmt.Sprintf("已选 %d 项", len(m.targetIDs())))
+	}
+
 	return " " + title + stats
 }
 
+func (m *Model) renderProjectTabs() string {
+	// 统计每个项目叶子任务的完成情况
+	childCounts := m.items.childCounts()
+	done := make(map[string]int)
+	total := make(map[string]int)
+	for _, item := range m.items {
+		if childCounts[item.id] > 0 {
+			continue
+		}
+		total[item.ProjectID]++
+		total[allProjectsID]++
+		if item.Done {
+			done[item.ProjectID]++
+			done[allProjectsID]++
+		}
+	}
+
+	var tabs []string
+	for _, project := range m.projectTabs() {
+		label := fmt.Sprintf(" %s %d/%d ", project.Name, done[project.id], total[project.id])
+		color := project.Color
+		if color == "" {
+			color = "62"
+		}
+		if project.id == m.currentProject {
+			tabs = append(tabs, m.styles.Header.
+				Background(lipgloss.Color(color)).
+				Foreground(lipgloss.Color("255")).
+				Render(label))
+		} else {
+			tabs = append(tabs, lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(label))
+		}
+	}
+	return " " + strings.Join(tabs, m.styles.TableBorder.Render("│"))
+}
+
 func (m *Model) renderEmptyState() string {
+	if m.searchQuery != "" {
+		return "  " + m.styles.Help.Render("没有匹配的任务，按 / 修改搜索，Esc 清除") + "\n"
+	}
+	if len(m.tagFilter) > 0 {
+		return "  " + m.styles.Help.Render("没有匹配的任务，按 t 修改过滤条件") + "\n"
+	}
 	return "  " + m.styles.Help.Render("暂无任务，按 a 开始添加") + "\n"
 }
 
@@ -63,6 +156,7 @@ func (m *Model) renderTodoTable() string {
 		statusColWidth   = 6  // [ ] 状态
 		priorityColWidth = 8  // "优先级"（3个中文字符，实际宽度为6，加2个空格）
 		titleColWidth    = 40 // 任务标题
+		tagsColWidth     = 16 // 标签
 		deadlineColWidth = 19 // 截止日期 (YYYY-MM-DD HH:MM)
 	)
 
@@ -77,20 +171,27 @@ func (m *Model) renderTodoTable() string {
 		m.styles.TableHeader.Width(statusColWidth).Render("状态"),
 		m.styles.TableHeader.Width(priorityColWidth).Render("优先级"),
 		m.styles.TableHeader.Width(titleColWidth).Render("任务"),
+		m.styles.TableHeader.Width(tagsColWidth).Render("标签"),
 		m.styles.TableHeader.Width(deadlineColWidth).Render("截止日期"),
 	)
 
 	// 表格行
 	var rows []string
-	for i, item := range m.items {
+	depths := m.items.depths()
+	childCounts := m.items.childCounts()
+	visible := m.visibleIndexes()
+	start, end := m.viewportRange(len(visible))
+	for i := start; i < end; i++ {
+		item := m.items[visible[i]]
 		// 判断是否是当前选中的行
 		isSelected := item.id == m.selectedID
-		row := m.renderTableRow(i, item, statusColWidth, priorityColWidth, titleColWidth, deadlineColWidth, isSelected)
+		treePrefix := m.treePrefix(item, depths[item.id], childCounts[item.id])
+		row := m.renderTableRow(i, item, treePrefix, statusColWidth, priorityColWidth, titleColWidth, tagsColWidth, deadlineColWidth, isSelected)
 		rows = append(rows, row)
 	}
 
 	// 计算总宽度
-	totalWidth := statusColWidth + priorityColWidth + titleColWidth + deadlineColWidth
+	totalWidth := statusColWidth + priorityColWidth + titleColWidth + tagsColWidth + deadlineColWidth
 
 	// 组合表格
 	table := lipgloss.JoinVertical(lipgloss.Left,
@@ -99,10 +200,25 @@ func (m *Model) renderTodoTable() string {
 	)
 	table += "\n" + strings.Join(rows, "\n")
 
-	return tableStyle.Render(table)
+	// 任务较多时只显示光标附近的一段，在表格下方提示位置
+	return tableStyle.Render(table) + "\n  " + m.renderScrollIndicator(start, end, len(visible))
 }
 
-func (m *Model) renderTableRow(index int, item TodoItem, statusWidth, priorityWidth, titleWidth, deadlineWidth int, isSelected bool) string {
+// 子任务缩进以及折叠标记
+func (m *Model) treePrefix(item TodoItem, depth, childCount int) string {
+	prefix := strings.Repeat("  ", depth)
+	switch {
+	case childCount > 0 && m.collapsed[item.id]:
+		return prefix + fmt.Sprintf("▸(%d) ", childCount)
+	case childCount > 0:
+		return prefix + "▾ "
+	case depth > 0:
+		return prefix + "· "
+	}
+	return prefix
+}
+
+func (m *Model) renderTableRow(index int, item TodoItem, treePrefix string, statusWidth, priorityWidth, titleWidth, tagsWidth, deadlineWidth int, isSelected bool) string {
 	// 状态列
 	var status string
 	if item.Done {
@@ -110,6 +226,9 @@ func (m *Model) renderTableRow(index int, item TodoItem, statusWidth, priorityWi
 	} else {
 		status = "○"
 	}
+	if m.isMarked(item.id) {
+		status = m.styles.Selected.Render("◆ ") + status
+	}
 	if isSelected {
 		status = m.styles.Cursor.Render("▶ ") + status
 	}
@@ -138,13 +257,11 @@ func (m *Model) renderTableRow(index int, item TodoItem, statusWidth, priorityWi
 	priorityCell := priorityStyle.Render(priority)
 
 	// 标题列
-	title := item.Title
-	if len(title) > titleWidth-2 {
-		title = title[:titleWidth-5] + "..."
-	}
+	title := truncateText(item.Title, titleWidth-2-lipgloss.Width(treePrefix))
 	if item.Done {
 		title = m.styles.Done.Render(title)
 	}
+	title = m.styles.Deadline.Render(treePrefix) + title
 
 	titleStyle := m.styles.TableHeader.Width(titleWidth).Align(lipgloss.Left)
 	if isSelected {
@@ -152,6 +269,14 @@ func (m *Model) renderTableRow(index int, item TodoItem, statusWidth, priorityWi
 	}
 	titleCell := titleStyle.Render(title)
 
+	// 标签列
+	tags := m.styles.Tag.Render(truncateText(formatTags(item.Tags), tagsWidth-1))
+	tagsStyle := m.styles.TableHeader.Width(tagsWidth).Align(lipgloss.Left)
+	if isSelected {
+		tagsStyle = tagsStyle.Background(lipgloss.Color("235"))
+	}
+	tagsCell := tagsStyle.Render(tags)
+
 	// 截止日期列
 	var deadline string
 	if item.HasDeadline {
@@ -164,6 +289,9 @@ func (m *Model) renderTableRow(index int, item TodoItem, statusWidth, priorityWi
 	} else {
 		deadline = m.styles.Deadline.Render("-")
 	}
+	if item.Recurrence.Enabled() {
+		deadline += m.styles.Tag.Render(" ↻")
+	}
 
 	deadlineStyle := m.styles.TableHeader.Width(deadlineWidth).Align(lipgloss.Left)
 	if isSelected {
@@ -172,7 +300,25 @@ func (m *Model) renderTableRow(index int, item TodoItem, statusWidth, priorityWi
 	deadlineCell := deadlineStyle.Render(deadline)
 
 	// 组合行 - 不再需要额外的背景色，因为每个单元格已经有了
-	return statusCell + priorityCell + titleCell + deadlineCell
+	return statusCell + priorityCell + titleCell + tagsCell + deadlineCell
+}
+
+// 按显示宽度截断文本，超出时以 … 结尾
+func truncateText(text string, width int) string {
+	if lipgloss.Width(text) <= width {
+		return text
+	}
+	var builder strings.Builder
+	used := 0
+	for _, r := range text {
+		w := lipgloss.Width(string(r))
+		if used+w >= width {
+			break
+		}
+		builder.WriteRune(r)
+		used += w
+	}
+	return builder.String() + "…"
 }
 
 func (m *Model) renderInteractiveArea() string {
@@ -181,12 +327,25 @@ func (m *Model) renderInteractiveArea() string {
 	switch m.mode {
 	case ModeNormal:
 		content = m.renderHelp()
-	case ModeInputTitle:
+	case ModeTrash:
+		content = "\n" + m.styles.Help.Render("  ↑/↓ 移动 • r 恢复 • x 彻底删除 • X 清空回收站 • Esc 返回") + "\n"
+	case ModeInputTitle, ModeSearch:
 		content = "\n  " + m.input.View() + "\n"
+	case ModeImport:
+		content = "\n" + m.styles.Help.Render("  ↑/↓ 移动 • Enter 导入新任务（跳过重复） • Esc 取消") + "\n"
+	case ModeEditNotes:
+		content = "\n" + lipgloss.NewStyle().PaddingLeft(2).Render(m.notes.View()) + "\n"
 	case ModePickPriority:
 		content = "\n" + m.renderPriorityPicker()
 	case ModePickDate:
 		content = "\n  " + m.renderDatePicker() + "\n"
+	case ModePickRecurrence:
+		content = "\n  " + m.renderRecurrencePicker() + "\n"
+	case ModeConfirm:
+		content = "\n  " + m.styles.Status.Render(m.confirm.prompt) + "\n" +
+			m.styles.Help.Render("  y/Enter 是 • n 否 • Esc 取消") + "\n"
+	case ModeConflict:
+		content = m.renderConflictPrompt()
 	}
 
 	return content
@@ -197,7 +356,11 @@ func (m *Model) renderHelp() string {
 		return ""
 	}
 	return "\n" + m.styles.Help.Render(
-		"  ↑/↓ 移动 • a 添加 • e 编辑 • 空格 完成 • x 删除 • q 退出",
+		"  ↑/↓ 移动 • a 添加 • e 编辑 • E 用编辑器编辑 • 空格 完成 • x 删除 • D 清除截止日期 • q 退出\n"+
+			"  A 添加子任务 • ←/→ 折叠/展开 • t 标签过滤 • / 搜索 • n 备注 • i 详情\n"+
+			"  tab 切换项目 • N 新建项目 • M 移动到项目 • Z 归档项目 • s 排序 • J/K 调整顺序\n"+
+			"  PgUp/PgDn 翻页 • ctrl+u/ctrl+d 半页 • g/G 首尾 • u 撤销 • ctrl+r 重做 • T 回收站 • o 导出 • I 导入\n"+
+			"  m 标记 • v 范围选择 • p 优先级 • + 顺延截止日期 • # 添加标签 • Esc 取消标记",
 	) + "\n"
 }
 
@@ -220,6 +383,10 @@ func (m *Model) renderPriorityPicker() string {
 }
 
 func (m *Model) renderDatePicker() string {
+	if m.datePicker.typing {
+		return m.input.View() + "\n  " + m.renderDatePreview()
+	}
+
 	date := m.datePicker.date
 
 	// 格式化和高亮选中的字段
@@ -240,3 +407,65 @@ func (m *Model) renderDatePicker() string {
 	return fmt.Sprintf("截止日期：%s-%s-%s %s:%s:%s",
 		year, month, day, hour, minute, second)
 }
+
+// 实时显示输入的日期会被解析成什么时间
+func (m *Model) renderDatePreview() string {
+	if strings.TrimSpace(m.input.Value()) == "" {
+		return m.styles.Help.Render("输入后这里显示解析结果")
+	}
+	date, err := dateparse.Parse(m.input.Value(), time.Now())
+	if err != nil {
+		return m.styles.Overdue.Render(err.Error())
+	}
+	return m.styles.Selected.Render(fmt.Sprintf("→ %s 周%s",
+		date.Format("2006-01-02 15:04"), weekdayNames[date.Weekday()]))
+}
+
+func (m *Model) renderRecurrencePicker() string {
+	rule := m.recurrencePicker.rule
+
+	// 高亮选中的字段
+	formatField := func(value string, field int) string {
+		if m.recurrencePicker.field == field {
+			return m.styles.Selected.Render(value)
+		}
+		return value
+	}
+
+	frequency := "不重复"
+	if rule.Enabled() {
+		frequency = "每" + rule.Frequency.String()
+	}
+	parts := []string{"重复：" + formatField(frequency, recurrenceFieldFrequency)}
+	if !rule.Enabled() {
+		return parts[0]
+	}
+
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	parts = append(parts, "间隔 "+formatField(fmt.Sprintf("%d", interval), recurrenceFieldInterval)+" "+rule.Frequency.String())
+
+	if rule.Frequency == FrequencyWeekly {
+		var days []string
+		for i, day := range weekdayOrder {
+			name := weekdayNames[day]
+			if rule.hasWeekday(day) {
+				name = m.styles.Checkbox.Render("[" + name + "]")
+			} else {
+				name = " " + name + " "
+			}
+			days = append(days, formatField(name, recurrenceFieldWeekday+i))
+		}
+		parts = append(parts, "星期 "+strings.Join(days, ""))
+	}
+
+	until := "不结束"
+	if !rule.Until.IsZero() {
+		until = rule.Until.Format("2006-01-02")
+	}
+	parts = append(parts, "结束："+formatField(until, recurrenceFieldUntil))
+
+	return strings.Join(parts, m.styles.Help.Render(" • "))
+}
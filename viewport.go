@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ====================== 滚动视口 ======================
+
+// 表格边框、表头和分隔线占用的行数
+const tableFrameLines = 4
+
+// 表格中能显示的任务行数，终端高度未知时不限制
+func (m *Model) tableCapacity() int {
+	if m.terminalHeight <= 0 {
+		return len(m.visibleIndexes())
+	}
+
+	// 与 View 的拼接方式保持一致，按换行符数量统计表格以外占用的行数
+	used := 1 // 开头的空行
+	if len(m.projects) > 0 {
+		used += strings.Count(m.renderProjectTabs(), "\n") + 2
+	}
+	used += strings.Count(m.renderHeader(), "\n") + 2
+	used += tableFrameLines - 1
+	used += 1 // 滚动位置提示
+	if detail := m.renderDetail(); detail != "" {
+		used += strings.Count(detail, "\n") + 1
+	}
+	used += strings.Count(m.renderInteractiveArea(), "\n")
+	if m.statusLine != "" {
+		used += 1
+	}
+	// 行数比换行符多一行，另外最后留一行空白，避免终端滚动
+	used += 2
+
+	capacity := m.terminalHeight - used
+	if capacity < 3 {
+		capacity = 3
+	}
+	return capacity
+}
+
+// 调整滚动位置，保证光标所在行可见
+func (m *Model) syncViewport() {
+	total := len(m.visibleIndexes())
+	rows := m.tableCapacity()
+
+	if m.cursor < m.scrollOffset {
+		m.scrollOffset = m.cursor
+	}
+	if m.cursor >= m.scrollOffset+rows {
+		m.scrollOffset = m.cursor - rows + 1
+	}
+	if m.scrollOffset > total-rows {
+		m.scrollOffset = total - rows
+	}
+	if m.scrollOffset < 0 {
+		m.scrollOffset = 0
+	}
+}
+
+// 当前窗口内的任务在可见列表中的起止位置（左闭右开）
+func (m *Model) viewportRange(total int) (int, int) {
+	start := m.scrollOffset
+	if start > total {
+		start = total
+	}
+	end := start + m.tableCapacity()
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// 滚动位置提示，例如 "12–40 / 312"，全部显示时为空
+func (m *Model) renderScrollIndicator(start, end, total int) string {
+	if start == 0 && end == total {
+		return ""
+	}
+	return m.styles.Help.Render(fmt.Sprintf("%d–%d / %d", start+1, end, total))
+}
+
+// 翻页：pages 为正向下，为负向上，0.5 表示半页
+func (m *Model) scrollPage(pages float64) {
+	rows := int(float64(m.tableCapacity()) * pages)
+	if rows == 0 {
+		rows = 1
+		if pages < 0 {
+			rows = -1
+		}
+	}
+	m.scrollOffset += rows
+	m.moveCursor(rows)
+}
+
+func (m *Model) jumpToTop() {
+	m.moveCursor(-m.cursor)
+}
+
+func (m *Model) jumpToBottom() {
+	m.moveCursor(len(m.visibleIndexes()) - 1 - m.cursor)
+}